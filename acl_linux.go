@@ -0,0 +1,34 @@
+// +build linux
+
+package rotate
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// aclAttr is an extended attribute holding a POSIX access ACL.
+const aclAttr = "system.posix_acl_access"
+
+// getACL returns an access ACL of f or nil if f has none.
+func getACL(f File) ([]byte, error) {
+	name := fmt.Sprintf("/proc/self/fd/%d", f.Fd())
+	n, err := syscall.Getxattr(name, aclAttr, nil)
+	if err == nil && n > 0 {
+		b := make([]byte, n)
+		n, err = syscall.Getxattr(name, aclAttr, b)
+		if err == nil {
+			return b[:n], nil
+		}
+	}
+	if err == syscall.ENODATA || err == syscall.ENOTSUP {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// setACL sets an access ACL of f.
+func setACL(f File, acl []byte) error {
+	name := fmt.Sprintf("/proc/self/fd/%d", f.Fd())
+	return syscall.Setxattr(name, aclAttr, acl, 0)
+}
@@ -0,0 +1,7 @@
+// +build !linux
+
+package rotate
+
+func getACL(f File) ([]byte, error) { return nil, nil }
+
+func setACL(f File, acl []byte) error { return nil }
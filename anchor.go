@@ -0,0 +1,68 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// anchorExt is an extension of an anchor file, see Config.Anchored.
+const anchorExt = ".anchor"
+
+// anchorName returns a name of an anchor file for base.
+func anchorName(base string) string {
+	return "." + base + anchorExt
+}
+
+// anchorer is implemented by rotators which persist time of the first
+// write to a current file.
+type anchorer interface {
+	// Anchor returns persisted time of the first write or zero time.
+	Anchor() time.Time
+	// SetAnchor persists t. Zero t removes an anchor.
+	SetAnchor(t time.Time) error
+}
+
+func (r *rotator) Anchor() time.Time {
+	b, err := ioutil.ReadFile(r.abs(anchorName(r.name)))
+	if err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(b)))
+	if err != nil {
+		return time.Time{}
+	}
+	return r.c.in(t)
+}
+
+func (r *rotator) SetAnchor(t time.Time) error {
+	name := r.abs(anchorName(r.name))
+	if t.IsZero() {
+		if err := remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(name, []byte(t.Format(time.RFC3339Nano)+"\n"), r.c.perm())
+}
+
+// anchor persists t as time of the first write to a current file.
+// See Config.Anchored.
+func (f *file) anchor(t time.Time) {
+	a, ok := f.r.(anchorer)
+	if !ok || !f.c.Anchored || f.c.Interval <= 0 {
+		return
+	}
+	if err := a.SetAnchor(t); err != nil {
+		f.emit(Event{Type: RotationFailed, Filename: f.w.Name(), Err: &Error{Filename: f.w.Name(), Err: err}})
+	}
+}
+
+// boundary returns the first boundary of Config.Interval after start.
+func (f *file) boundary(start time.Time) time.Time {
+	if f.c.Anchored {
+		return start.Add(f.c.Interval)
+	}
+	return nextBoundary(start, f.c.Interval)
+}
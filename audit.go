@@ -0,0 +1,81 @@
+package rotate
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// auditExt is an extension of an audit file, see Config.Audit.
+const auditExt = ".audit"
+
+// auditName returns a name of an audit file for base.
+func auditName(base string) string {
+	return "." + base + auditExt
+}
+
+// AuditRecord describes a retention decision, see Config.Audit.
+//
+// Action is "remove" or "compress". Policy is a reason of the action:
+// "count", "maxage", "totalbytes" or "budget" (see Manager) for removal
+// and "compress" for compression.
+type AuditRecord struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	File   string    `json:"file"`
+	Policy string    `json:"policy"`
+}
+
+// audit appends records of action applied to rotated files names by
+// policy to an audit file.
+func (r *rotator) audit(action, policy string, names ...string) error {
+	if !r.c.Audit || len(names) == 0 {
+		return nil
+	}
+	t := r.c.now()
+	var b []byte
+	for _, s := range names {
+		line, err := json.Marshal(AuditRecord{Time: t, Action: action, File: s, Policy: policy})
+		if err != nil {
+			return err
+		}
+		b = append(append(b, line...), '\n')
+	}
+	s := auditName(r.name)
+	f, err := openFile(r.abs(s), os.O_APPEND|os.O_CREATE|os.O_WRONLY, r.mode.Perm())
+	if err == nil {
+		_, err = f.Write(b)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return &Error{Filename: s, Err: err}
+	}
+	return nil
+}
+
+// ReadAudit returns records of an audit file of a file with name from
+// the oldest. A missing audit file has no records.
+func ReadAudit(name string) ([]AuditRecord, error) {
+	f, err := os.Open(filepath.Join(filepath.Dir(name), auditName(filepath.Base(name))))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []AuditRecord
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var v AuditRecord
+		if err := json.Unmarshal(s.Bytes(), &v); err != nil {
+			return nil, err
+		}
+		records = append(records, v)
+	}
+	return records, s.Err()
+}
@@ -0,0 +1,83 @@
+package rotate
+
+import "bytes"
+
+// maxBatch is a capacity of a batch buffer kept between writes.
+const maxBatch = 64 * 1024
+
+// batcher is implemented by files which can write batches, see WriteBatch.
+type batcher interface {
+	WriteBatch(lines [][]byte) (int, error)
+}
+
+// WriteBatch writes lines, e.g. records buffered by a logger, with
+// the lock acquired once. Rotation is checked once before a batch, so
+// a whole batch is written to a single file with a single write and never
+// split by SplitWrites. Lines are written as is, so they must end with
+// a newline.
+//
+// With policies changing writes, e.g. Config.Stamp or Config.JSONLines,
+// lines are written one by one under the lock, as if by Write.
+// n is a total number of bytes of lines written.
+func (r *Rotor) WriteBatch(lines [][]byte) (n int, err error) {
+	if v, ok := r.f.(batcher); ok {
+		return v.WriteBatch(lines)
+	}
+	for _, b := range lines {
+		m, err := r.f.Write(b)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (f *file) WriteBatch(lines [][]byte) (n int, err error) {
+	f.mu.Lock()
+	if f.plain() {
+		f.batch = f.batch[:0]
+		for _, b := range lines {
+			f.batch = append(f.batch, b...)
+		}
+		n, err = f.putRaw(f.batch)
+		if cap(f.batch) > maxBatch {
+			f.batch = nil
+		}
+	} else {
+		for _, b := range lines {
+			var m int
+			m, err = f.write(b)
+			if n += m; err != nil {
+				break
+			}
+		}
+	}
+	total := f.total
+	infos := f.infos
+	f.infos = nil
+	f.mu.Unlock()
+	if f.c.OnWrite != nil {
+		f.c.OnWrite(n, total)
+	}
+	f.onRotate(infos)
+	return
+}
+
+// plain reports whether writes are put to a file as is.
+func (f *file) plain() bool {
+	c := f.c
+	return f.dedup == nil && f.bucket == nil && !c.JSONLines && !c.Multiline &&
+		c.Stamp == "" && c.Encoding != UTF16LE && c.Sample == nil &&
+		c.Redact == nil && !c.Sanitize.enabled()
+}
+
+// WriteBatch writes a batch as a single message, so that it is kept in
+// a single shard.
+func (s *sharded) WriteBatch(lines [][]byte) (int, error) {
+	return s.Write(bytes.Join(lines, nil))
+}
+
+func (h *handle) WriteBatch(lines [][]byte) (int, error) {
+	return (&Rotor{h.File}).WriteBatch(lines)
+}
@@ -0,0 +1,108 @@
+package rotate
+
+import (
+	"os"
+	"sync"
+)
+
+// DefaultBufferSize is a size of a BufferedFile buffer by default.
+const DefaultBufferSize = 64 * 1024
+
+// BufferedFile buffers writes to Rotor until Flush, for callers building
+// their own batching. A buffer is written with WriteBatch, so that
+// rotation happens only at flush boundaries and writes are never split
+// between files unless Config.SplitWrites splits them.
+//
+// A buffer is flushed once it holds size bytes, by Sync and by Close.
+// Unlike Sync, Flush does not sync a file to disk.
+// BufferedFile is safe for concurrent use. It implements File.
+type BufferedFile struct {
+	r    *Rotor
+	size int
+	mu   sync.Mutex
+	buf  []byte
+}
+
+// NewBufferedFile returns BufferedFile writing to r with a buffer of
+// size bytes. If size <= 0, DefaultBufferSize is used.
+func NewBufferedFile(r *Rotor, size int) *BufferedFile {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &BufferedFile{r: r, size: size}
+}
+
+func (b *BufferedFile) Fd() uintptr                { return b.r.Fd() }
+func (b *BufferedFile) Name() string               { return b.r.Name() }
+func (b *BufferedFile) Stat() (os.FileInfo, error) { return b.r.Stat() }
+
+// Write buffers p. A buffer is flushed once it holds size bytes, so
+// a write is never split between flushes.
+func (b *BufferedFile) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) < b.size {
+		return len(p), nil
+	}
+	if err := b.flush(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (b *BufferedFile) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// Buffered returns a number of bytes buffered.
+func (b *BufferedFile) Buffered() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.buf)
+}
+
+// Flush writes buffered data to a file, flushing Config.Shards as well.
+// Rotation is checked once before the data is written. On error, data
+// which is not written is dropped.
+func (b *BufferedFile) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flush()
+}
+
+func (b *BufferedFile) flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.r.WriteBatch([][]byte{b.buf})
+	b.buf = b.buf[:0]
+	if cap(b.buf) > 2*b.size {
+		b.buf = nil // a large write is not retained
+	}
+	if err != nil {
+		return err
+	}
+	return b.r.Flush()
+}
+
+// Sync flushes a buffer and syncs a file.
+func (b *BufferedFile) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.flush(); err != nil {
+		return err
+	}
+	return b.r.Sync()
+}
+
+// Close flushes a buffer and closes a file.
+func (b *BufferedFile) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.flush()
+	if cerr := b.r.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"io"
+	"os/exec"
+)
+
+// Capture sets cmd.Stdout and cmd.Stderr to files stdout and stderr
+// opened with Open, so that output of a child process is rotated. If
+// stderr is "" or stdout, both streams are merged into a single file.
+// Capture must be called before cmd.Start.
+//
+// Call returned wait instead of cmd.Wait: it waits for cmd and its output
+// to be copied and then closes the files. wait must be called even if
+// cmd fails to start, so that the files are closed.
+//
+// A file which is not rotated on a current system is written as is,
+// like Pool does.
+func Capture(cmd *exec.Cmd, stdout, stderr string, c Config) (wait func() error, err error) {
+	var files []io.Closer
+	closeAll := func() (err error) {
+		for _, f := range files {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+	out, err := Open(stdout, c)
+	if err != nil && err != ErrNotSupported {
+		return nil, err
+	}
+	files = append(files, out)
+	cmd.Stdout = out
+	cmd.Stderr = out // the same writer shares a single pipe
+	if stderr != "" && stderr != stdout {
+		e, err := Open(stderr, c)
+		if err != nil && err != ErrNotSupported {
+			_ = closeAll()
+			return nil, err
+		}
+		files = append(files, e)
+		cmd.Stderr = e
+	}
+	return func() error {
+		err := cmd.Wait() // copying goroutines finish before Wait returns
+		if cerr := closeAll(); err == nil {
+			err = cerr
+		}
+		return err
+	}, nil
+}
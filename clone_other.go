@@ -0,0 +1,8 @@
+// +build !linux
+
+package rotate
+
+import "os"
+
+// clone is not supported.
+func clone(dst, src *os.File) error { return ErrNotSupported }
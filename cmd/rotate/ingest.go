@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+// entry is a line in format of Docker json-file logging driver.
+type entry struct {
+	Log    string    `json:"log"`
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+}
+
+// ingest reads lines from stdin (e.g. a container's output) and writes them
+// to a rotated file, like Docker json-file logging driver does.
+//
+//     docker logs -f app | rotate ingest -o app.log -max-size 10m -max-file 3
+//
+func ingest(args []string) error {
+	fs := flag.NewFlagSet("ingest", flag.ExitOnError)
+	name := fs.String("o", "out.log", "output file")
+	size := fs.String("max-size", "10m", "maximum size of a file (e.g. 512k, 10m, 1g)")
+	count := fs.Int64("max-file", 5, "maximum count of files")
+	raw := fs.Bool("raw", false, "write lines as is instead of JSON")
+	stream := fs.String("stream", "stdout", "stream name recorded in JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	bytes, err := rotate.ParseBytes(*size)
+	if err != nil {
+		return err
+	}
+	f, err := rotate.Open(*name, rotate.Config{Bytes: bytes, Count: *count})
+	if err != nil && err != rotate.ErrNotSupported {
+		return err
+	}
+	defer f.Close()
+
+	return copyLines(f, os.Stdin, func(line string) ([]byte, error) {
+		if *raw {
+			return []byte(line), nil
+		}
+		b, err := json.Marshal(entry{Log: line, Stream: *stream, Time: time.Now().UTC()})
+		return append(b, '\n'), err
+	})
+}
+
+// copyLines writes each line read from r encoded with enc to w.
+// A single write per line keeps lines whole across rotations.
+func copyLines(w io.Writer, r io.Reader, enc func(string) ([]byte, error)) error {
+	buf := bufio.NewReader(r)
+	for {
+		line, err := buf.ReadString('\n')
+		if len(line) > 0 {
+			b, eerr := enc(line)
+			if eerr != nil {
+				return eerr
+			}
+			if _, werr := w.Write(b); werr != nil {
+				if _, ok := werr.(*rotate.Error); !ok {
+					return werr
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
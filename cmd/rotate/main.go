@@ -0,0 +1,57 @@
+// Command rotate is a command line tool for rotated files.
+//
+// Usage:
+//
+//     rotate <command> [flags]
+//
+// Commands:
+//
+//     ingest    write lines from stdin to a rotated file
+//     migrate   rename rotated files to another naming scheme
+//     verify    check integrity of rotated files
+//     repair    fix problems found by verify
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"ingest", "write lines from stdin to a rotated file", ingest},
+	{"migrate", "rename rotated files to another naming scheme", migrate},
+	{"verify", "check integrity of rotated files", verify},
+	{"repair", "fix problems found by verify", repair},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "rotate %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rotate <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s%s\n", c.name, c.usage)
+	}
+	os.Exit(2)
+}
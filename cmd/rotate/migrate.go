@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+
+	"github.com/koorgoo/rotate"
+)
+
+// migrate renames rotated files of a file to another naming scheme.
+//
+//     rotate migrate -from numeric -to timestamp /var/log/app.log
+//
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := rotate.Numeric
+	to := rotate.Numeric
+	fs.Var(&from, "from", "current naming scheme (numeric, zero-padded, timestamp)")
+	fs.Var(&to, "to", "target naming scheme (numeric, zero-padded, timestamp)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("a single file is required")
+	}
+	name := fs.Arg(0)
+	return rotate.Migrate(filepath.Dir(name), filepath.Base(name), from, to)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/koorgoo/rotate"
+)
+
+// repair fixes rotated files of a file and prints steps taken.
+//
+//     rotate repair /var/log/app.log
+//
+func repair(args []string) error {
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("a single file is required")
+	}
+	name := fs.Arg(0)
+	steps, err := rotate.Repair(filepath.Dir(name), filepath.Base(name))
+	for _, s := range steps {
+		fmt.Println(s)
+	}
+	return err
+}
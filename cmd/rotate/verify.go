@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/koorgoo/rotate"
+)
+
+// verify prints a JSON report of integrity of rotated files of a file.
+// It fails if problems are found.
+//
+//     rotate verify /var/log/app.log
+//
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("a single file is required")
+	}
+	name := fs.Arg(0)
+	rep, err := rotate.Verify(filepath.Dir(name), filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+	if err := e.Encode(rep); err != nil {
+		return err
+	}
+	if !rep.OK() {
+		return fmt.Errorf("%d problems found", len(rep.Problems))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// gzipExt is an extension of compressed files.
+const gzipExt = ".gz"
+
+// compression is a compression of a rotated file in the background.
+type compression struct {
+	name string        // of a rotated file
+	id   string        // of a rotated file, see Config.IDs
+	done chan struct{} // closed once err is set
+	err  error         // of gzipFile
+}
+
+// compress starts compression of the last rotated file if Config.Compress
+// is set, so that writes are not blocked meanwhile. It is completed by
+// compressed.
+func (r *rotator) compress() error {
+	if !r.c.Compress || len(r.names) < 2 || r.names[1] == "" {
+		return nil
+	}
+	s := r.names[1]
+	if strings.HasSuffix(s, gzipExt) {
+		return nil
+	}
+	z := &compression{name: s, id: r.sealedID, done: make(chan struct{})}
+	src, dst, mode, gid := r.abs(s), r.abs(s+gzipExt), r.mode, r.gid
+	go func() {
+		z.err = gzipFile(src, dst, mode, gid)
+		close(z.done)
+	}()
+	r.zip = z
+	return nil
+}
+
+// compressed waits for compression started by compress, if any, and
+// completes it: a rotated file is renamed in a chain, stamped with its ID,
+// linked and protected. It must be called before a chain is changed.
+func (r *rotator) compressed() error {
+	z := r.zip
+	if z == nil {
+		return nil
+	}
+	r.zip = nil
+	s := z.name
+	if <-z.done; z.err != nil {
+		err := &Error{Filename: s, Err: z.err}
+		if perr := r.protect(); perr != nil {
+			return errorList{err, perr}
+		}
+		return err
+	}
+	for i := 1; i < len(r.names); i++ {
+		if r.names[i] == s {
+			r.names[i] = s + gzipExt
+		}
+	}
+	var errs errorList
+	if err := r.audit("compress", "compress", s); err != nil {
+		errs = append(errs, err)
+	}
+	if r.c.IDs && z.id != "" {
+		if err := r.idError(s+gzipExt, setID(r.abs(s+gzipExt), z.id)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := r.link(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.protect(); err != nil {
+		errs = append(errs, err)
+	}
+	if r.c.TotalBytes > 0 {
+		r.usage()
+	}
+	return errs.err()
+}
+
+// waiter is implemented by rotators which work in the background, see
+// Config.Compress.
+type waiter interface {
+	// wait waits for work in the background and returns its error.
+	wait() error
+}
+
+func (r *rotator) wait() error { return r.compressed() }
+
+// compressor is implemented by rotators which compress rotated files in
+// the background, see Config.Compress.
+type compressor interface {
+	// compressing returns compression of the last rotated file or nil.
+	compressing() *compression
+}
+
+func (r *rotator) compressing() *compression { return r.zip }
+
+// gzipFile compresses src to dst and removes src. Data is written to
+// a temporary file first, so that dst is never seen partially written.
+// dst gets modification time of src.
+func gzipFile(src, dst string, mode os.FileMode, gid int) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	v, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	tmp := dst + tmpExt
+	out, err := createFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode, gid)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = remove(tmp)
+		}
+	}()
+	z := gzip.NewWriter(out)
+	_, err = io.Copy(z, in)
+	if err == nil {
+		err = z.Close()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err = rename(tmp, dst); err != nil {
+		return err
+	}
+	_ = os.Chtimes(dst, v.ModTime(), v.ModTime())
+	return remove(src)
+}
+
+// tmpExt is an extension of temporary files.
+const tmpExt = ".tmp"
+
+// decompress returns a UTF-8 reader of f decompressing it if needed.
+// See Config.Encoding.
+func decompress(f *os.File) (io.Reader, error) {
+	if strings.HasSuffix(f.Name(), gzipExt) {
+		z, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		return decodeBOM(z), nil
+	}
+	return decodeBOM(f), nil
+}
@@ -0,0 +1,86 @@
+// +build linux
+
+package rotate_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_compress(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3, Compress: true})
+	defer r.Close()
+
+	// trigger rotations
+	write(t, r, "1")
+	write(t, r, "2")
+	write(t, r, "3")
+	if err := r.Sync(); err != nil { // waits for compression
+		t.Fatal(err)
+	}
+
+	exist(t, root, "a.1.gz")
+	exist(t, root, "a.2.gz")
+	notExist(t, root, "a.1")
+	notExist(t, root, "a.3.gz")
+
+	rd, err := rotate.NewReader(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+	b, err := ioutil.ReadAll(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "123" {
+		t.Fatalf("want %q, got %q", "123", b)
+	}
+}
+
+func TestFile_compressOnRotate(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var got []string
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3, Compress: true, OnRotate: func(info rotate.RotationInfo) {
+		time.Sleep(50 * time.Millisecond) // longer than compression
+		f, err := os.Open(info.Filename)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer f.Close()
+		z, err := gzip.NewReader(f)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, err := ioutil.ReadAll(z)
+		if err != nil {
+			t.Error(err)
+		}
+		got = append(got, filepath.Base(info.Filename)+":"+string(b))
+	}})
+	defer r.Close()
+
+	// trigger rotations
+	write(t, r, "1")
+	write(t, r, "2")
+	write(t, r, "3")
+
+	want := []string{"a.1.gz:1", "a.1.gz:2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,102 @@
+package rotate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// Not all architectures define them in syscall. oTmpfile differs by
+// architecture, see tmpfile_*.go.
+const (
+	atFdcwd         = -0x64
+	atSymlinkFollow = 0x400
+)
+
+// createFile creates a new file for rotation. The file is created
+// anonymously with O_TMPFILE, gets mode and then is linked to name, so that
+// a file with wrong permissions is never visible, even after a crash.
+// The file is chgrp'ed to gid unless gid < 0.
+// It falls back to openFile if O_TMPFILE is not supported or name exists.
+func createFile(name string, flag int, mode os.FileMode, gid int) (*os.File, error) {
+	f, err := createTmp(name, flag, mode, gid, nil)
+	if err == nil {
+		return f, nil
+	}
+	if f, err = openFile(name, flag, mode); err != nil {
+		return nil, err
+	}
+	if err = chgrp(f, gid); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// createInit is like createFile, but the file is initialized with init
+// before it is linked to name. See Config.Promote.
+func createInit(name string, flag int, mode os.FileMode, gid int, init func(io.Writer) error) (*os.File, error) {
+	var ierr error
+	f, err := createTmp(name, flag, mode, gid, func(f *os.File) error {
+		ierr = init(f)
+		return ierr
+	})
+	if ierr != nil {
+		return nil, ierr
+	}
+	if err == nil {
+		return f, nil
+	}
+	return promote(name, flag, mode, gid, init)
+}
+
+// createTmp creates an anonymous file, calls init with it unless init is
+// nil and links it to name.
+func createTmp(name string, flag int, mode os.FileMode, gid int, init func(*os.File) error) (*os.File, error) {
+	flag = flag&^(os.O_CREATE|os.O_EXCL|os.O_TRUNC) | oTmpfile | syscall.O_CLOEXEC
+	fd, err := syscall.Open(filepath.Dir(name), flag, uint32(mode.Perm()))
+	if err != nil {
+		return nil, err
+	}
+	f := os.NewFile(uintptr(fd), name)
+	err = f.Chmod(mode)
+	if err == nil {
+		err = chgrp(f, gid)
+	}
+	if err == nil && init != nil {
+		err = init(f)
+	}
+	if err == nil {
+		err = linkat(fmt.Sprintf("/proc/self/fd/%d", fd), name)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func linkat(oldpath, newpath string) error {
+	p0, err := syscall.BytePtrFromString(oldpath)
+	if err != nil {
+		return err
+	}
+	p1, err := syscall.BytePtrFromString(newpath)
+	if err != nil {
+		return err
+	}
+	dirfd := atFdcwd
+	_, _, e := syscall.Syscall6(
+		syscall.SYS_LINKAT,
+		uintptr(dirfd), uintptr(unsafe.Pointer(p0)),
+		uintptr(dirfd), uintptr(unsafe.Pointer(p1)),
+		atSymlinkFollow, 0,
+	)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+// +build !linux
+
+package rotate
+
+import (
+	"io"
+	"os"
+)
+
+// createFile creates a new file for rotation.
+// The file is chgrp'ed to gid unless gid < 0.
+func createFile(name string, flag int, mode os.FileMode, gid int) (*os.File, error) {
+	f, err := openFile(name, flag, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err = chgrp(f, gid); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// createInit is like createFile, but the file is initialized with init
+// before it appears at name. See Config.Promote.
+func createInit(name string, flag int, mode os.FileMode, gid int, init func(io.Writer) error) (*os.File, error) {
+	return promote(name, flag, mode, gid, init)
+}
@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// dedup tracks runs of identical writes.
+type dedup struct {
+	window time.Duration
+	equal  func(a, b []byte) bool
+	last   []byte
+	since  time.Time
+	n      int // skipped repeats
+}
+
+func newDedup(window time.Duration, equal func(a, b []byte) bool) *dedup {
+	if window <= 0 {
+		return nil
+	}
+	if equal == nil {
+		equal = bytes.Equal
+	}
+	return &dedup{window: window, equal: equal}
+}
+
+// Repeat reports whether b repeats a current run at t. If so, b is counted.
+func (d *dedup) Repeat(b []byte, t time.Time) bool {
+	if d.last == nil || t.Sub(d.since) >= d.window || !d.equal(d.last, b) {
+		return false
+	}
+	d.n++
+	return true
+}
+
+// Reset starts a new run with b at t.
+func (d *dedup) Reset(b []byte, t time.Time) {
+	d.last = append(d.last[:0], b...)
+	d.since = t
+	d.n = 0
+}
+
+// Flush returns a message about skipped repeats of a current run or nil.
+func (d *dedup) Flush() []byte {
+	if d.n == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("last message repeated %d times\n", d.n)
+	d.n = 0
+	return []byte(msg)
+}
@@ -0,0 +1,44 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_dedup(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	f, err := open(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Writes are deduplicated where rotation is not supported as well.
+	r, err := rotate.Wrap(f, rotate.Config{Dedup: time.Hour})
+	if err != nil && err != rotate.ErrNotSupported {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"x\n", "x\n", "x\n", "y\n", "y\n"} {
+		if n := write(t, r, s); n != len(s) {
+			t.Fatalf("want %d bytes, wrote %d bytes", len(s), n)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "x\nlast message repeated 2 times\ny\nlast message repeated 1 times\n"
+	if string(b) != want {
+		t.Fatalf("want %q, got %q", want, b)
+	}
+}
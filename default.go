@@ -0,0 +1,40 @@
+package rotate
+
+import (
+	"os"
+	"sync"
+)
+
+// std is a file written by package-level Write, see SetDefault.
+var std = struct {
+	sync.RWMutex
+	f File
+}{f: os.Stderr}
+
+// SetDefault sets a file written by package-level Write, WriteString and
+// Sync, like log.SetOutput does for log functions. Until SetDefault is
+// called, os.Stderr is written. A nil f restores os.Stderr.
+func SetDefault(f File) {
+	if f == nil {
+		f = os.Stderr
+	}
+	std.Lock()
+	std.f = f
+	std.Unlock()
+}
+
+// Default returns a file set by SetDefault.
+func Default() File {
+	std.RLock()
+	defer std.RUnlock()
+	return std.f
+}
+
+// Write writes b to a default file, see SetDefault.
+func Write(b []byte) (int, error) { return Default().Write(b) }
+
+// WriteString writes s to a default file, see SetDefault.
+func WriteString(s string) (int, error) { return Default().WriteString(s) }
+
+// Sync syncs a default file, see SetDefault.
+func Sync() error { return Default().Sync() }
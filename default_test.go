@@ -0,0 +1,47 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestSetDefault(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	f, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Bytes: 2, Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rotate.SetDefault(f)
+	defer rotate.SetDefault(nil)
+	if _, err := rotate.WriteString("1\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotate.Write([]byte("2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rotate.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"a": "2\n", "a.1": "1\n"} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+
+	rotate.SetDefault(nil)
+	if rotate.Default() != os.Stderr {
+		t.Error("want os.Stderr restored")
+	}
+}
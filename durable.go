@@ -0,0 +1,63 @@
+package rotate
+
+import "os"
+
+// rotatedSyncer is implemented by rotators which can sync rotated files.
+type rotatedSyncer interface {
+	// SyncRotated syncs files rotated since the last call and their
+	// directory.
+	SyncRotated() error
+}
+
+// syncRotated syncs rotated files if Config.SyncRotated is set.
+func (f *file) syncRotated() error {
+	if !f.c.SyncRotated {
+		return nil
+	}
+	if v, ok := f.r.(rotatedSyncer); ok {
+		return v.SyncRotated()
+	}
+	return nil
+}
+
+func (r *rotator) SyncRotated() error {
+	if err := r.compressed(); err != nil {
+		return err
+	}
+	if r.unsynced == 0 {
+		return nil
+	}
+	// The newest rotated files are at the head of a chain.
+	for i := 1; i <= r.unsynced && i < len(r.names); i++ {
+		s := r.names[i]
+		if s == "" {
+			continue
+		}
+		if err := syncRotatedFile(r.abs(s)); err != nil && !os.IsNotExist(err) {
+			return &Error{Filename: s, Err: err}
+		}
+	}
+	if err := syncRotatedDir(r.root); err != nil {
+		return &Error{Filename: r.root, Err: err}
+	}
+	r.unsynced = 0
+	return nil
+}
+
+// Syncs of SyncRotated, replaced in tests.
+var (
+	syncRotatedFile = syncFile
+	syncRotatedDir  = syncDir
+)
+
+func syncFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package rotate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding defines an encoding of files for consumers which require one,
+// e.g. Windows tools.
+type Encoding int
+
+// Encodings.
+const (
+	// UTF8 writes data as is.
+	UTF8 Encoding = iota
+	// UTF8BOM writes a UTF-8 byte order mark at the start of each file.
+	UTF8BOM
+	// UTF16LE writes a UTF-16LE byte order mark at the start of each file
+	// and transcodes writes from UTF-8 to UTF-16LE.
+	UTF16LE
+)
+
+var encodings = map[Encoding]string{
+	UTF8:    "utf-8",
+	UTF8BOM: "utf-8-bom",
+	UTF16LE: "utf-16le",
+}
+
+func (e Encoding) String() string {
+	if s, ok := encodings[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// Byte order marks.
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+)
+
+// bom returns a byte order mark of e.
+func (e Encoding) bom() []byte {
+	switch e {
+	case UTF8BOM:
+		return bomUTF8
+	case UTF16LE:
+		return bomUTF16LE
+	}
+	return nil
+}
+
+// encode returns UTF-8 b in e. Invalid UTF-8 is replaced with U+FFFD.
+func (e Encoding) encode(b []byte) []byte {
+	if e != UTF16LE {
+		return b
+	}
+	out := make([]byte, 0, 2*len(b))
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		if r > 0xffff {
+			r1, r2 := utf16.EncodeRune(r)
+			out = append(out, byte(r1), byte(r1>>8), byte(r2), byte(r2>>8))
+			continue
+		}
+		out = append(out, byte(r), byte(r>>8))
+	}
+	return out
+}
+
+// putEncoded puts b transcoded to Config.Encoding. A number of bytes of
+// b written is estimated for a partial write.
+func (f *file) putEncoded(b []byte) (int, error) {
+	e := f.c.Encoding.encode(b)
+	n, err := f.putRaw(e)
+	if n < len(e) {
+		return n * len(b) / len(e), err
+	}
+	return len(b), err
+}
+
+// decodeBOM returns a UTF-8 reader of r, which may start with a byte
+// order mark of Encoding. A mark is skipped.
+func decodeBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	b, _ := br.Peek(len(bomUTF8))
+	switch {
+	case bytes.HasPrefix(b, bomUTF8):
+		_, _ = br.Discard(len(bomUTF8))
+	case bytes.HasPrefix(b, bomUTF16LE):
+		_, _ = br.Discard(len(bomUTF16LE))
+		return &utf16Reader{r: br}
+	}
+	return br
+}
+
+// utf16Reader transcodes UTF-16LE to UTF-8.
+type utf16Reader struct {
+	r   *bufio.Reader
+	buf []byte // transcoded, but not read
+}
+
+func (u *utf16Reader) Read(p []byte) (n int, err error) {
+	for len(u.buf) < len(p) && err == nil {
+		var r rune
+		if r, err = u.unit(); err == nil && utf16.IsSurrogate(r) {
+			var r2 rune
+			if r2, err = u.unit(); err == nil {
+				r = utf16.DecodeRune(r, r2)
+			}
+		}
+		if err == nil {
+			var b [utf8.UTFMax]byte
+			u.buf = append(u.buf, b[:utf8.EncodeRune(b[:], r)]...)
+		}
+		if u.r.Buffered() == 0 && len(u.buf) > 0 {
+			break // do not block while data is available
+		}
+	}
+	n = copy(p, u.buf)
+	u.buf = u.buf[n:]
+	if n > 0 {
+		err = nil
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF // an odd trailing byte is dropped
+	}
+	return
+}
+
+// unit reads a UTF-16LE code unit.
+func (u *utf16Reader) unit() (rune, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		return 0, err
+	}
+	return rune(b[0]) | rune(b[1])<<8, nil
+}
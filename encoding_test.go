@@ -0,0 +1,77 @@
+// +build linux
+
+package rotate_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+var EncodingTests = []struct {
+	Encoding rotate.Encoding
+	Header   func(w io.Writer) error
+	Files    map[string]string
+}{
+	{
+		rotate.UTF8BOM,
+		rotate.CSVHeader("x"),
+		map[string]string{
+			"a.1": "\xef\xbb\xbfx\nü\n",
+			"a":   "\xef\xbb\xbfx\nb\n",
+		},
+	},
+	{
+		rotate.UTF16LE,
+		nil,
+		map[string]string{
+			"a.1": "\xff\xfe\xfc\x00\n\x00",
+			"a":   "\xff\xfeb\x00\n\x00",
+		},
+	},
+}
+
+func TestFile_encoding(t *testing.T) {
+	for _, tt := range EncodingTests {
+		root := touch(t, "a")
+		defer os.RemoveAll(root)
+
+		r := ropen(t, root, "a", rotate.Config{Bytes: 6, Count: 3, Encoding: tt.Encoding, Header: tt.Header})
+		write(t, r, "ü\n")
+		write(t, r, "b\n") // rotation
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		for name, want := range tt.Files {
+			b, err := ioutil.ReadFile(filepath.Join(root, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != want {
+				t.Errorf("%s: %s: want %q, got %q", tt.Encoding, name, want, b)
+			}
+		}
+
+		rd, err := rotate.NewReader(filepath.Join(root, "a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rd)
+		_ = rd.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "ü\nb\n"
+		if tt.Header != nil {
+			want = "x\nü\nx\nb\n"
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", tt.Encoding, want, b)
+		}
+	}
+}
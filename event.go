@@ -0,0 +1,95 @@
+package rotate
+
+import "fmt"
+
+// EventType identifies Event.
+type EventType int
+
+// Event types.
+const (
+	// RotationLimited is emitted when rotation is skipped because of
+	// Config.MaxRotations.
+	RotationLimited EventType = iota + 1
+	// WritesDropped is emitted when writes pass again after some were
+	// dropped because of Config.BytesPerSec or Config.Quota.
+	// N is a number of dropped writes.
+	WritesDropped
+	// FileReopened is emitted when a file removed or renamed by external
+	// tools is reopened. See Config.Watch.
+	FileReopened
+	// FileTruncated is emitted when a file truncated by external tools is
+	// detected. N is a new size.
+	FileTruncated
+	// WatchFailed is emitted when a file cannot be checked or reopened.
+	// See Config.Watch.
+	WatchFailed
+	// RotationFailed is emitted when rotation fails. Err is *Error.
+	// Writes continue to the current file and rotation is retried on
+	// next write. See Config.RotationErrors.
+	//
+	// If a file was renamed, but a new one can not be created, e.g. as
+	// directory permissions were changed, writes continue to the renamed
+	// file and creation is retried with backoff up to a minute.
+	RotationFailed
+	// FlushFailed is emitted when buffered writes fail to be flushed in
+	// background. Err is the write error. See Config.Shards.
+	FlushFailed
+	// PruneFailed is emitted when rotated files can not be removed to fit
+	// a budget of Manager. Err is *Error or a list of them.
+	PruneFailed
+	// FileEvicted is emitted when a file of Pool is closed to fit a limit
+	// of SetPoolMaxOpen. It is reopened on next use.
+	FileEvicted
+	// OrphanRemoved is emitted for each artifact of a crashed run removed
+	// on Wrap, see Config.CleanOrphans. If Err is not nil, the file is
+	// not removed.
+	OrphanRemoved
+	// RotationSlow is emitted once a rotation takes longer than
+	// Config.SlowRotation. Stage is a stage the rotation is at, e.g.
+	// "rename 2 of 5", "reopen" or "compress". The event is emitted while
+	// the rotation is in progress, concurrently with other events.
+	RotationSlow
+)
+
+var eventTypes = map[EventType]string{
+	RotationLimited: "rotation limited",
+	WritesDropped:   "writes dropped",
+	FileReopened:    "file reopened",
+	FileTruncated:   "file truncated",
+	WatchFailed:     "watch failed",
+	RotationFailed:  "rotation failed",
+	FlushFailed:     "flush failed",
+	PruneFailed:     "prune failed",
+	FileEvicted:     "file evicted",
+	OrphanRemoved:   "orphan removed",
+	RotationSlow:    "rotation slow",
+}
+
+func (t EventType) String() string {
+	if s, ok := eventTypes[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event describes a notable situation which does not fail write.
+type Event struct {
+	Type     EventType
+	Filename string
+	Err      error
+	N        int64
+	Stage    string // see RotationSlow
+}
+
+func (e Event) String() string {
+	if e.Stage != "" {
+		return fmt.Sprintf("rotate: %s: %s: %s", e.Filename, e.Type, e.Stage)
+	}
+	if e.N != 0 {
+		return fmt.Sprintf("rotate: %s: %s: %d", e.Filename, e.Type, e.N)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("rotate: %s: %s: %v", e.Filename, e.Type, e.Err)
+	}
+	return fmt.Sprintf("rotate: %s: %s", e.Filename, e.Type)
+}
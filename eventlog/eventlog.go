@@ -0,0 +1,139 @@
+// Package eventlog mirrors high-severity lines written to a rotated file
+// to the Windows Event Log, so that Windows services are monitored by
+// usual tools while a full stream goes to the file.
+//
+//     f, err := rotate.Open(`C:\logs\app.log`, c)
+//     ...
+//     w, err := eventlog.New(f, eventlog.Options{Source: "app"})
+//     ...
+//     log.SetOutput(w)
+//
+// An event source should be registered on install of a service, e.g. with
+// New-EventLog PowerShell cmdlet. Otherwise Windows shows a warning with
+// each message.
+package eventlog
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/koorgoo/rotate"
+)
+
+// Severity is a severity of a line.
+type Severity int
+
+// Severities.
+const (
+	Info Severity = iota + 1
+	Warning
+	Error
+)
+
+var severities = map[Severity]string{
+	Info:    "info",
+	Warning: "warning",
+	Error:   "error",
+}
+
+func (s Severity) String() string {
+	if v, ok := severities[s]; ok {
+		return v
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Options configures Writer.
+type Options struct {
+	// Source is a name of an event source.
+	Source string
+	// Severity returns a severity of a line. Defaults to DefaultSeverity.
+	Severity func(line []byte) Severity
+	// Min is a minimal severity of mirrored lines. If Min == 0, Error is
+	// used.
+	Min Severity
+	// OnError is called when a line is not reported.
+	OnError func(error)
+}
+
+// DefaultSeverity returns Error for lines containing "ERROR", "FATAL" or
+// "PANIC", Warning for lines containing "WARN" and Info otherwise. Case is
+// ignored.
+func DefaultSeverity(line []byte) Severity {
+	s := bytes.ToUpper(line)
+	for _, v := range []string{"ERROR", "FATAL", "PANIC"} {
+		if bytes.Contains(s, []byte(v)) {
+			return Error
+		}
+	}
+	if bytes.Contains(s, []byte("WARN")) {
+		return Warning
+	}
+	return Info
+}
+
+// logger reports messages to an event log.
+type logger interface {
+	report(s Severity, msg string) error
+	close() error
+}
+
+// Writer writes to a file and mirrors lines of Options.Min severity to
+// the Windows Event Log. A line is mirrored once it is written.
+type Writer struct {
+	rotate.File
+	opt Options
+	log logger
+}
+
+// New returns Writer of f. The Event Log is closed with f by Close.
+// rotate.ErrNotSupported is returned on systems other than Windows.
+func New(f rotate.File, opt Options) (*Writer, error) {
+	if opt.Severity == nil {
+		opt.Severity = DefaultSeverity
+	}
+	if opt.Min == 0 {
+		opt.Min = Error
+	}
+	l, err := open(opt.Source)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{File: f, opt: opt, log: l}, nil
+}
+
+func (w *Writer) Write(b []byte) (int, error) {
+	n, err := w.File.Write(b)
+	w.mirror(b[:n])
+	return n, err
+}
+
+func (w *Writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// mirror reports lines of b of a minimal severity.
+func (w *Writer) mirror(b []byte) {
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			continue
+		}
+		s := w.opt.Severity(line)
+		if s < w.opt.Min {
+			continue
+		}
+		if err := w.log.report(s, string(line)); err != nil && w.opt.OnError != nil {
+			w.opt.OnError(err)
+		}
+	}
+}
+
+// Close closes a file and the Event Log.
+func (w *Writer) Close() error {
+	err := w.File.Close()
+	if lerr := w.log.close(); err == nil {
+		err = lerr
+	}
+	return err
+}
@@ -0,0 +1,7 @@
+// +build !windows
+
+package eventlog
+
+import "github.com/koorgoo/rotate"
+
+func open(source string) (logger, error) { return nil, rotate.ErrNotSupported }
@@ -0,0 +1,25 @@
+package eventlog_test
+
+import (
+	"testing"
+
+	"github.com/koorgoo/rotate/eventlog"
+)
+
+var DefaultSeverityTests = []struct {
+	Line     string
+	Severity eventlog.Severity
+}{
+	{"started", eventlog.Info},
+	{"level=warning disk is slow", eventlog.Warning},
+	{"ERROR: failed", eventlog.Error},
+	{"panic: runtime error", eventlog.Error},
+}
+
+func TestDefaultSeverity(t *testing.T) {
+	for _, tt := range DefaultSeverityTests {
+		if s := eventlog.DefaultSeverity([]byte(tt.Line)); s != tt.Severity {
+			t.Errorf("%q: want %s, got %s", tt.Line, tt.Severity, s)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package eventlog
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+)
+
+// Event types of ReportEvent.
+const (
+	errorType       = 0x1
+	warningType     = 0x2
+	informationType = 0x4
+)
+
+// eventID is an identifier of all reported events.
+const eventID = 1
+
+type eventLog struct{ h uintptr }
+
+func open(source string) (logger, error) {
+	p, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(p)))
+	if h == 0 {
+		return nil, err
+	}
+	return &eventLog{h}, nil
+}
+
+func (l *eventLog) report(s Severity, msg string) error {
+	typ := informationType
+	switch s {
+	case Warning:
+		typ = warningType
+	case Error:
+		typ = errorType
+	}
+	p, err := syscall.UTF16PtrFromString(strings.Replace(msg, "\x00", "", -1))
+	if err != nil {
+		return err
+	}
+	strs := []*uint16{p}
+	r, _, err := procReportEvent.Call(l.h, uintptr(typ), 0, eventID, 0,
+		uintptr(len(strs)), 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (l *eventLog) close() error {
+	if r, _, err := procDeregisterEventSource.Call(l.h); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package rotate
+
+// SetSyncer replaces syncs of files and a directory by SyncRotated with fn
+// until restore is called.
+func SetSyncer(fn func(name string) error) (restore func()) {
+	file, dir := syncRotatedFile, syncRotatedDir
+	syncRotatedFile, syncRotatedDir = fn, fn
+	return func() { syncRotatedFile, syncRotatedDir = file, dir }
+}
@@ -0,0 +1,94 @@
+package rotate
+
+import (
+	"container/list"
+	"sync"
+)
+
+// fds limits files open by pools, see SetPoolMaxOpen.
+var fds = struct {
+	sync.Mutex
+	max       int
+	lru       *list.List // of *pooled, the most recently used first
+	evictions int64
+}{lru: list.New()}
+
+// SetPoolMaxOpen limits a number of files open by all pools to n, so that
+// a process with a low limit of file descriptors does not run out of them.
+// The least recently used files are closed beyond the limit and reopened
+// on next use; Pool emits FileEvicted for each of them. If n == 0, the
+// number is not limited.
+//
+// Only files of Pool are counted and evicted. Files of Open and Wrap are
+// never closed behind a caller's back, so they must fit into a limit of
+// file descriptors next to n.
+func SetPoolMaxOpen(n int) {
+	fds.Lock()
+	fds.max = n
+	fds.Unlock()
+	evictFiles()
+}
+
+// Evictions returns a number of files closed to fit SetPoolMaxOpen.
+func Evictions() int64 {
+	fds.Lock()
+	defer fds.Unlock()
+	return fds.evictions
+}
+
+// use marks an open file as the most recently used.
+func (f *pooled) use() {
+	fds.Lock()
+	if f.elem == nil {
+		f.elem = fds.lru.PushFront(f)
+	} else {
+		fds.lru.MoveToFront(f.elem)
+	}
+	fds.Unlock()
+}
+
+// forget removes a closed file from the limit.
+func (f *pooled) forget() {
+	fds.Lock()
+	if f.elem != nil {
+		fds.lru.Remove(f.elem)
+		f.elem = nil
+	}
+	fds.Unlock()
+}
+
+// evictFiles closes the least recently used files beyond SetPoolMaxOpen.
+// It must not be called under a lock of a pooled file.
+func evictFiles() {
+	for {
+		fds.Lock()
+		if fds.max <= 0 || fds.lru.Len() <= fds.max {
+			fds.Unlock()
+			return
+		}
+		f := fds.lru.Remove(fds.lru.Back()).(*pooled)
+		f.elem = nil
+		fds.Unlock()
+		f.evict()
+	}
+}
+
+// evict closes a file unless it has been used since removal from fds.lru.
+func (f *pooled) evict() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fds.Lock()
+	used := f.elem != nil
+	fds.Unlock()
+	if f.f == nil || used {
+		return
+	}
+	err := f.f.Close()
+	f.f = nil
+	fds.Lock()
+	fds.evictions++
+	fds.Unlock()
+	if f.p.c.OnEvent != nil {
+		f.p.c.OnEvent(Event{Type: FileEvicted, Filename: f.name, Err: err})
+	}
+}
@@ -0,0 +1,37 @@
+package rotate
+
+import "flag"
+
+// RegisterFlags defines flags of a rotation policy in fs and returns Config
+// set by them once fs is parsed. Flag names are prefixed with prefix:
+//
+//     -<prefix>size      Config.Bytes in human units (e.g. 512k, 10MB)
+//     -<prefix>count     Config.Count
+//     -<prefix>interval  Config.Interval (e.g. 24h)
+//     -<prefix>compress  Config.Compress
+//
+// If fs is nil, flag.CommandLine is used.
+func RegisterFlags(fs *flag.FlagSet, prefix string) *Config {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	c := new(Config)
+	fs.Var((*bytesValue)(&c.Bytes), prefix+"size", "maximum size of a file (e.g. 512k, 10MB)")
+	fs.Int64Var(&c.Count, prefix+"count", 0, "maximum count of files (current + rotated)")
+	fs.DurationVar(&c.Interval, prefix+"interval", 0, "rotation interval aligned to midnight (e.g. 1h, 24h)")
+	fs.BoolVar(&c.Compress, prefix+"compress", false, "compress rotated files with gzip")
+	return c
+}
+
+// bytesValue is a flag.Value parsed with ParseBytes.
+type bytesValue int64
+
+func (b *bytesValue) String() string { return FormatBytes(int64(*b)) }
+
+func (b *bytesValue) Set(s string) error {
+	n, err := ParseBytes(s)
+	if err == nil {
+		*b = bytesValue(n)
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package rotate_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c := rotate.RegisterFlags(fs, "log-")
+
+	args := []string{"-log-size", "10MB", "-log-count", "3", "-log-interval", "24h", "-log-compress"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	want := rotate.Config{Bytes: 10 * rotate.MB, Count: 3, Interval: 24 * time.Hour, Compress: true}
+	if !reflect.DeepEqual(*c, want) {
+		t.Fatalf("want %v, got %v", want, *c)
+	}
+}
+
+func TestRegisterFlags_invalidSize(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	rotate.RegisterFlags(fs, "")
+
+	if err := fs.Parse([]string{"-size", "10x"}); err == nil {
+		t.Fatal("want error")
+	}
+}
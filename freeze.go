@@ -0,0 +1,45 @@
+package rotate
+
+import "os"
+
+// WithFrozenFile calls fn with a path of a current file of f while
+// rotation of f is held, so that backup tools can snapshot or hash the file
+// without a rename racing them. Buffered writes (see Config.Shards and
+// Config.Dedup) are flushed before fn is called. Writes continue to the
+// file meanwhile and rotation happens on the first write after fn returns.
+// A rotation scheduled with ScheduleRotation waits for fn to return.
+//
+// If f is not rotated on a current system, fn is called with f.Name().
+func WithFrozenFile(f File, fn func(path string) error) error {
+	ff, ok := unwrap(f)
+	if !ok {
+		return fn(f.Name())
+	}
+	if v, ok := f.(flusher); ok {
+		if err := v.Flush(); err != nil {
+			return err
+		}
+	}
+	ff.hold.RLock()
+	defer ff.hold.RUnlock()
+
+	ff.mu.Lock()
+	if ff.closed {
+		ff.mu.Unlock()
+		return os.ErrClosed
+	}
+	err := ff.flushDedup()
+	ff.frozen++
+	path := ff.w.Name()
+	ff.mu.Unlock()
+
+	defer func() {
+		ff.mu.Lock()
+		ff.frozen--
+		ff.mu.Unlock()
+	}()
+	if err != nil {
+		return err
+	}
+	return fn(path)
+}
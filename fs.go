@@ -0,0 +1,50 @@
+// +build !windows
+
+package rotate
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+func openFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+func remove(name string) error { return os.Remove(name) }
+
+// syncDir syncs a directory, so that renames in it are durable.
+func syncDir(name string) error { return syncFile(name) }
+
+// lookupGroup returns an ID of a group by a name or an ID.
+// It returns -1 if s is empty.
+func lookupGroup(s string) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+	if gid, err := strconv.Atoi(s); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(s)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// chgrp changes a group of f to gid unless gid < 0.
+func chgrp(f *os.File, gid int) error {
+	if gid < 0 {
+		return nil
+	}
+	return f.Chown(-1, gid)
+}
+
+// mkfifo creates a named pipe.
+func mkfifo(name string, perm os.FileMode) error {
+	return syscall.Mkfifo(name, uint32(perm.Perm()))
+}
@@ -0,0 +1,140 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errSharingViolation is ERROR_SHARING_VIOLATION.
+const errSharingViolation syscall.Errno = 32
+
+// Attempts made while a file is busy or pending deletion.
+const (
+	retries    = 10
+	retryDelay = 10 * time.Millisecond
+)
+
+// openFile is like os.OpenFile, but shares a file for deletion, so that it
+// can be renamed or removed while open (e.g. by rotation or external tools).
+func openFile(name string, flag int, perm os.FileMode) (f *os.File, err error) {
+	err = retry(func() error {
+		f, err = open(name, flag, perm)
+		return err
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f, nil
+}
+
+func open(name string, flag int, perm os.FileMode) (*os.File, error) {
+	p, err := syscall.UTF16PtrFromString(longPath(name))
+	if err != nil {
+		return nil, err
+	}
+	var access uint32
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_RDONLY:
+		access = syscall.GENERIC_READ
+	case os.O_WRONLY:
+		access = syscall.GENERIC_WRITE
+	case os.O_RDWR:
+		access = syscall.GENERIC_READ | syscall.GENERIC_WRITE
+	}
+	if flag&os.O_APPEND != 0 {
+		access &^= syscall.GENERIC_WRITE
+		access |= syscall.FILE_APPEND_DATA
+	}
+	var mode uint32
+	switch {
+	case flag&(os.O_CREATE|os.O_EXCL) == (os.O_CREATE | os.O_EXCL):
+		mode = syscall.CREATE_NEW
+	case flag&(os.O_CREATE|os.O_TRUNC) == (os.O_CREATE | os.O_TRUNC):
+		mode = syscall.CREATE_ALWAYS
+	case flag&os.O_CREATE != 0:
+		mode = syscall.OPEN_ALWAYS
+	case flag&os.O_TRUNC != 0:
+		mode = syscall.TRUNCATE_EXISTING
+	default:
+		mode = syscall.OPEN_EXISTING
+	}
+	var attrs uint32 = syscall.FILE_ATTRIBUTE_NORMAL
+	if perm&0200 == 0 {
+		attrs = syscall.FILE_ATTRIBUTE_READONLY
+	}
+	share := uint32(syscall.FILE_SHARE_READ | syscall.FILE_SHARE_WRITE | syscall.FILE_SHARE_DELETE)
+	h, err := syscall.CreateFile(p, access, share, nil, mode, attrs, 0)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(h), name), nil
+}
+
+// maxPath is a limit of a legacy path length. A directory path is limited to
+// MAX_PATH minus 8.3 file name (12 characters).
+const maxPath = 248
+
+// longPath returns an extended-length path for an absolute path exceeding
+// maxPath, so that it can be passed to Windows API. os package does the same
+// for its functions, but not for syscall.CreateFile used by openFile.
+func longPath(s string) string {
+	if len(s) < maxPath || strings.HasPrefix(s, `\\?\`) || !filepath.IsAbs(s) {
+		return s
+	}
+	s = filepath.Clean(s)
+	if strings.HasPrefix(s, `\\`) {
+		return `\\?\UNC\` + s[len(`\\`):]
+	}
+	return `\\?\` + s
+}
+
+// rename is like os.Rename, but retries while a file is busy.
+func rename(oldpath, newpath string) error {
+	oldpath, newpath = longPath(oldpath), longPath(newpath)
+	return retry(func() error { return os.Rename(oldpath, newpath) })
+}
+
+// remove is like os.Remove, but retries while a file is busy.
+func remove(name string) error {
+	name = longPath(name)
+	return retry(func() error { return os.Remove(name) })
+}
+
+// retry calls fn until it does not fail with access denied or sharing
+// violation. They are reported while a file is open without sharing or
+// a name is pending deletion.
+func retry(fn func() error) (err error) {
+	for i := 0; i < retries; i++ {
+		if err = fn(); !busy(err) {
+			return
+		}
+		time.Sleep(time.Duration(i+1) * retryDelay)
+	}
+	return
+}
+
+func busy(err error) bool {
+	switch v := err.(type) {
+	case *os.PathError:
+		err = v.Err
+	case *os.LinkError:
+		err = v.Err
+	}
+	return err == syscall.ERROR_ACCESS_DENIED || err == errSharingViolation
+}
+
+// syncDir is a noop as directories can not be synced on Windows.
+// NTFS journals metadata changes such as renames.
+func syncDir(name string) error { return nil }
+
+// lookupGroup returns -1, as groups of files are not supported.
+func lookupGroup(s string) (int, error) { return -1, nil }
+
+// chgrp is a noop, as groups of files are not supported.
+func chgrp(f *os.File, gid int) error { return nil }
+
+// mkfifo returns ErrNotSupported, as named pipes are not files on Windows.
+func mkfifo(name string, perm os.FileMode) error { return ErrNotSupported }
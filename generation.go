@@ -0,0 +1,65 @@
+package rotate
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// OpenRotation opens a single rotated file of base in root by generation.
+// A compressed file is decompressed.
+//
+// If a rotation set has a manifest (see Config.Manifest), generation is
+// RotationInfo.Generation, which the manifest records by file name.
+// Otherwise generation counts rotated files from
+// the newest: 1 is the previous file.
+func OpenRotation(root, base string, generation int) (io.ReadCloser, error) {
+	base = filepath.Base(base)
+	notExist := &os.PathError{
+		Op:   "open",
+		Path: filepath.Join(root, base) + "@" + strconv.Itoa(generation),
+		Err:  os.ErrNotExist,
+	}
+	names, err := List(root, base)
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	for _, s := range names {
+		if s != base {
+			rotated = append(rotated, s)
+		}
+	}
+	m, err := loadManifest(filepath.Join(root, manifestName(base)))
+	if err != nil {
+		return nil, err
+	}
+	var name string
+	if m.Generation > 0 {
+		name = m.lookup(rotated, int64(generation))
+	} else if i := generation - 1; i >= 0 && i < len(rotated) {
+		name = rotated[i]
+	}
+	if name == "" {
+		return nil, notExist
+	}
+	f, err := os.Open(filepath.Join(root, name))
+	if err != nil {
+		return nil, err
+	}
+	z, err := decompress(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &readCloser{z, f}, nil
+}
+
+// readCloser reads from r and closes c.
+type readCloser struct {
+	io.Reader
+	c io.Closer
+}
+
+func (r *readCloser) Close() error { return r.c.Close() }
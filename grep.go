@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+)
+
+// Grep writes lines of a rotation set of a file base in root for which match
+// returns true to w. Files are scanned from the newest to the oldest, lines
+// of a single file are written in order. Compressed files are decompressed.
+func Grep(root, base string, match func([]byte) bool, w io.Writer) error {
+	files, err := openSet(filepath.Join(root, filepath.Base(base)), nil)
+	if err != nil {
+		return err
+	}
+	defer closeAll(files)
+
+	for i := len(files) - 1; i >= 0; i-- {
+		r, err := decompress(files[i])
+		if err != nil {
+			return err
+		}
+		if err = grep(r, match, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func grep(r io.Reader, match func([]byte) bool, w io.Writer) error {
+	buf := bufio.NewReader(r)
+	for {
+		line, err := buf.ReadBytes('\n')
+		if len(line) > 0 && match(line) {
+			if _, werr := w.Write(line); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
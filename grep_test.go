@@ -0,0 +1,31 @@
+package rotate_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestGrep(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	fill(t, root, map[string]string{
+		"a":   "err 5\nok\n",
+		"a.1": "err 3\nok\nerr 4\n",
+		"a.2": "err 1\nerr 2",
+	})
+
+	var buf bytes.Buffer
+	match := func(b []byte) bool { return bytes.HasPrefix(b, []byte("err")) }
+	if err := rotate.Grep(root, "a", match, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "err 5\nerr 3\nerr 4\nerr 1\nerr 2"
+	if buf.String() != want {
+		t.Fatalf("want %q, got %q", want, buf.String())
+	}
+}
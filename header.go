@@ -0,0 +1,118 @@
+package rotate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"os"
+	"reflect"
+)
+
+// header writes a byte order mark of Config.Encoding and Config.Header
+// to an empty current file. A header written by prepare is counted only.
+func (f *file) header() error {
+	if f.primed != nil {
+		f.count(f.primed)
+		f.primed = nil
+		return nil
+	}
+	b, err := f.headerBytes()
+	if err != nil {
+		return &Error{Filename: f.w.Name(), Err: err}
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	n, err := writeFull(f.w, b)
+	f.count(b[:n])
+	if err != nil {
+		return &Error{Filename: f.w.Name(), Err: err}
+	}
+	return nil
+}
+
+// headerBytes returns a header of a new file.
+func (f *file) headerBytes() ([]byte, error) {
+	b := f.c.Encoding.bom()
+	if f.c.Header != nil {
+		var buf bytes.Buffer
+		if err := f.c.Header(&buf); err != nil {
+			return nil, err
+		}
+		b = append(append([]byte(nil), b...), f.c.Encoding.encode(buf.Bytes())...)
+	}
+	return b, nil
+}
+
+// CSVHeader returns Config.Header writing a CSV row of fields.
+func CSVHeader(fields ...string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		c := csv.NewWriter(w)
+		if err := c.Write(fields); err != nil {
+			return err
+		}
+		c.Flush()
+		return c.Error()
+	}
+}
+
+// CSVReader reads CSV records of a rotation set, from the oldest rotated
+// file to a current one. Compressed files are decompressed. The first row
+// of the oldest file is a header. The first row of other files is skipped
+// if it repeats the header (see CSVHeader).
+type CSVReader struct {
+	files  []*os.File
+	cur    *csv.Reader // reader of files[0]
+	first  bool        // whether cur is at a first row
+	header []string
+}
+
+// NewCSVReader returns CSVReader for a rotation set of a file with name.
+func NewCSVReader(name string) (*CSVReader, error) {
+	files, err := openSet(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVReader{files: files}, nil
+}
+
+// Read returns a next record or io.EOF if no records are left.
+func (r *CSVReader) Read() ([]string, error) {
+	for len(r.files) > 0 {
+		if r.cur == nil {
+			z, err := decompress(r.files[0])
+			if err != nil {
+				return nil, err
+			}
+			r.cur = csv.NewReader(z)
+			r.cur.FieldsPerRecord = -1
+			r.first = true
+		}
+		v, err := r.cur.Read()
+		if err == io.EOF {
+			r.cur = nil
+			_ = r.files[0].Close()
+			r.files = r.files[1:]
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		first := r.first
+		r.first = false
+		if first && r.header == nil {
+			r.header = v
+		} else if first && reflect.DeepEqual(v, r.header) {
+			continue
+		}
+		return v, nil
+	}
+	return nil, io.EOF
+}
+
+// Close closes files left to read.
+func (r *CSVReader) Close() error {
+	err := closeAll(r.files)
+	r.files = nil
+	return err
+}
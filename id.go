@@ -0,0 +1,68 @@
+package rotate
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// idAttr is an extended attribute holding an ID of a file.
+const idAttr = "user.rotate.id"
+
+// ID returns an ID stamped on a file with name by Config.IDs or "" if
+// the file has none. ErrNotSupported is returned on systems or filesystems
+// without extended attributes.
+func ID(name string) (string, error) {
+	return getID(name)
+}
+
+// newID returns a random UUID (version 4).
+func newID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// identifier is implemented by rotators which stamp files with IDs.
+type identifier interface {
+	// SealedID returns an ID of the last rotated file.
+	SealedID() string
+}
+
+func (r *rotator) SealedID() string { return r.sealedID }
+
+// identify stamps a current file with an ID unless it has one.
+func (r *rotator) identify() error {
+	id, err := getID(r.abs(r.name))
+	if err == nil && id == "" {
+		id = newID()
+		err = setID(r.abs(r.name), id)
+	}
+	r.id = id
+	return r.idError(r.name, err)
+}
+
+// reidentify moves an ID of a current file to a rotated one and stamps
+// a current file with a new ID.
+func (r *rotator) reidentify() error {
+	r.sealedID = r.id
+	if r.c.Method == CopyTruncate && len(r.names) > 1 && r.names[1] != "" {
+		// A copy is a new file, while a current one is truncated.
+		if err := setID(r.abs(r.names[1]), r.id); err != nil {
+			return r.idError(r.names[1], err)
+		}
+	}
+	r.id = newID()
+	return r.idError(r.name, setID(r.abs(r.name), r.id))
+}
+
+// idError wraps err of stamping a file s. Missing support is not an error.
+func (r *rotator) idError(s string, err error) error {
+	if err == nil || err == ErrNotSupported {
+		return nil
+	}
+	return &Error{Filename: s, Err: err}
+}
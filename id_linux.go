@@ -0,0 +1,27 @@
+// +build linux
+
+package rotate
+
+import "syscall"
+
+func getID(name string) (string, error) {
+	b := make([]byte, 64)
+	n, err := syscall.Getxattr(name, idAttr, b)
+	switch err {
+	case nil:
+		return string(b[:n]), nil
+	case syscall.ENODATA:
+		return "", nil
+	case syscall.ENOTSUP:
+		return "", ErrNotSupported
+	}
+	return "", err
+}
+
+func setID(name, id string) error {
+	err := syscall.Setxattr(name, idAttr, []byte(id), 0)
+	if err == syscall.ENOTSUP {
+		return ErrNotSupported
+	}
+	return err
+}
@@ -0,0 +1,7 @@
+// +build !linux
+
+package rotate
+
+func getID(name string) (string, error) { return "", ErrNotSupported }
+
+func setID(name, id string) error { return ErrNotSupported }
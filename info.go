@@ -0,0 +1,92 @@
+package rotate
+
+import "time"
+
+// RotationInfo describes a file sealed by rotation.
+type RotationInfo struct {
+	// Filename is a path of a rotated file.
+	// It is empty if the file was removed (see Config.Count).
+	// With Config.Compress, Config.OnRotate is called once the file is
+	// compressed, so Filename is of a compressed file unless compression
+	// fails. The file is renamed and removed by next rotations, so it
+	// should be opened before OnRotate returns.
+	Filename string
+	// Size is a size of the file at rotation.
+	Size int64
+	// First and Last are times of the first and the last write to the file.
+	// First is zero if the file was not empty on Wrap.
+	First time.Time
+	Last  time.Time
+	// Generation numbers sealed files of a rotation set from 1, so that
+	// a file can be identified regardless of its name, which changes as
+	// the chain shifts. It is 0 unless Config.Manifest is set.
+	Generation int64
+	// ID is an ID of the file if Config.IDs is set.
+	ID string
+	// Hash is a sum of Config.Hash of the file content. It is nil if
+	// the file was not empty on Wrap or was changed by external tools.
+	Hash []byte
+	// Duration is time spent to rename rotated files and reopen a file,
+	// see Stats.
+	Duration time.Duration
+
+	zip *compression // of the file, see onRotate
+}
+
+// sealer is implemented by rotators which know a name of a rotated file.
+type sealer interface {
+	// Sealed returns a path of the last rotated file or "" if it was removed.
+	Sealed() string
+}
+
+// sealed returns RotationInfo for a rotated file of size rotated in d and
+// records it for Config.OnRotate.
+func (f *file) sealed(size int64, d time.Duration) RotationInfo {
+	info := RotationInfo{
+		Size:     size,
+		First:    f.first,
+		Last:     f.last,
+		Duration: d,
+	}
+	if v, ok := f.r.(sealer); ok {
+		info.Filename = v.Sealed()
+	}
+	if v, ok := f.r.(generationer); ok {
+		info.Generation = v.Generation()
+	}
+	if v, ok := f.r.(identifier); ok {
+		info.ID = v.SealedID()
+	}
+	if f.hash != nil {
+		info.Hash = f.hash.Sum(nil)
+	}
+	if v, ok := f.r.(compressor); ok {
+		info.zip = v.compressing()
+	}
+	if f.c.OnRotate != nil {
+		f.infos = append(f.infos, info)
+	}
+	f.first = time.Time{}
+	f.last = time.Time{}
+	f.anchor(time.Time{})
+	return info
+}
+
+func (f *file) onRotate(infos []RotationInfo) {
+	for _, info := range infos {
+		// A rotated file is removed once compressed.
+		if z := info.zip; z != nil {
+			if <-z.done; z.err == nil {
+				info.Filename += gzipExt
+			}
+		}
+		f.c.OnRotate(info)
+	}
+}
+
+func (r *rotator) Sealed() string {
+	if len(r.names) < 2 || r.names[1] == "" {
+		return ""
+	}
+	return r.abs(r.names[1])
+}
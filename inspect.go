@@ -0,0 +1,97 @@
+package rotate
+
+import (
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// Set is a read-only view of a rotation set, e.g. for monitoring agents
+// inspecting directories written by other processes. It never changes
+// files on disk. Files and Generation are read by Inspect and Refresh;
+// readers list files anew.
+type Set struct {
+	Root string
+	Base string
+	// Files are files of a set from the newest: a current file if it
+	// exists and rotated ones.
+	Files []FileInfo
+	// Generation is a generation of the last sealed file if a set has
+	// a manifest (see Config.Manifest) and 0 otherwise.
+	Generation int64
+}
+
+// SetStats are statistics of a Set.
+type SetStats struct {
+	Files      int
+	Bytes      int64
+	Compressed int
+	// Oldest and Newest are modification times of the oldest and
+	// the newest files.
+	Oldest time.Time
+	Newest time.Time
+}
+
+// Inspect returns Set of base in root.
+func Inspect(root, base string) (*Set, error) {
+	s := &Set{Root: root, Base: filepath.Base(base)}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh reads files and a manifest of s again.
+func (s *Set) Refresh() error {
+	files, err := ListInfo(s.Root, s.Base, ListOptions{})
+	if err != nil {
+		return err
+	}
+	m, err := loadManifest(filepath.Join(s.Root, manifestName(s.Base)))
+	if err != nil {
+		return err
+	}
+	s.Files, s.Generation = files, m.Generation
+	return nil
+}
+
+// Stats returns statistics of files of s.
+func (s *Set) Stats() SetStats {
+	var v SetStats
+	for _, f := range s.Files {
+		v.Files++
+		v.Bytes += f.Size
+		if f.Compressed {
+			v.Compressed++
+		}
+		if v.Oldest.IsZero() || f.ModTime.Before(v.Oldest) {
+			v.Oldest = f.ModTime
+		}
+		if f.ModTime.After(v.Newest) {
+			v.Newest = f.ModTime
+		}
+	}
+	return v
+}
+
+func (s *Set) name() string { return filepath.Join(s.Root, s.Base) }
+
+// NewReader is like package function NewReader.
+func (s *Set) NewReader() (*Reader, error) { return NewReader(s.name()) }
+
+// NewRecords is like package function NewRecords.
+func (s *Set) NewRecords() (*Records, error) { return NewRecords(s.name()) }
+
+// NewGroups is like package function NewGroups.
+func (s *Set) NewGroups() (*Groups, error) { return NewGroups(s.name()) }
+
+// OpenRotation is like package function OpenRotation.
+func (s *Set) OpenRotation(generation int) (io.ReadCloser, error) {
+	return OpenRotation(s.Root, s.Base, generation)
+}
+
+// ReadAudit is like package function ReadAudit.
+func (s *Set) ReadAudit() ([]AuditRecord, error) { return ReadAudit(s.name()) }
+
+// Verify is like package function Verify.
+func (s *Set) Verify() (Report, error) { return Verify(s.Root, s.Base) }
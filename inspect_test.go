@@ -0,0 +1,66 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestInspect(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+	for name, s := range map[string]string{
+		"a":           "3\n",
+		"a.1":         "2\n",
+		"a.2":         "1\n",
+		".a.manifest": `{"generation":2,"files":{"a.1":{"generation":2},"a.2":{"generation":1}}}`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := rotate.Inspect(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, v := range s.Files {
+		names = append(names, v.Name)
+	}
+	if want := []string{"a", "a.1", "a.2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("want %v, got %v", want, names)
+	}
+	if s.Generation != 2 {
+		t.Errorf("want generation 2, got %d", s.Generation)
+	}
+	if v := s.Stats(); v.Files != 3 || v.Bytes != 6 {
+		t.Errorf("want 3 files of 6 bytes, got %d of %d", v.Files, v.Bytes)
+	}
+
+	r, err := s.NewReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "1\n2\n3\n" {
+		t.Errorf("want %q, got %q", "1\n2\n3\n", b)
+	}
+
+	g, err := s.OpenRotation(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if b, _ := ioutil.ReadAll(g); string(b) != "2\n" {
+		t.Errorf("generation 2: want %q, got %q", "2\n", b)
+	}
+}
@@ -0,0 +1,197 @@
+// Package journald writes systemd journal entries to rotated files per
+// unit, for hosts which keep flat files of selected units with retention
+// policies of rotate.
+//
+//     journalctl -f -o export -u app.service | app-ingest
+//
+// where app-ingest calls Ingest with os.Stdin:
+//
+//     p := rotate.NewPool(rotate.Config{Bytes: 10 * rotate.MB, Count: 5})
+//     defer p.Close()
+//     err := journald.Ingest(os.Stdin, p, journald.Options{Dir: "/var/log/units"})
+//
+package journald
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+// Options configures Ingest.
+type Options struct {
+	// Dir is a directory of files. A file of a unit is named <unit>.log.
+	Dir string
+	// Units selects units to write. If empty, all units are written.
+	Units []string
+}
+
+// TimeFormat is a format of time of an entry prefixing a line.
+const TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// ErrFormat is returned when an entry is malformed.
+var ErrFormat = errors.New("journald: invalid format")
+
+// Ingest reads journal entries in export or JSON format (journalctl -o
+// export or -o json) from r until EOF and writes each message as a line
+// to a file of its unit opened by p. A line is prefixed with time of an
+// entry in UTC formatted with TimeFormat. Entries without a unit are
+// skipped.
+func Ingest(r io.Reader, p *rotate.Pool, opt Options) error {
+	selected := make(map[string]bool, len(opt.Units))
+	for _, s := range opt.Units {
+		selected[s] = true
+	}
+	br := bufio.NewReader(r)
+	next := func() (entry, error) { return readExport(br) }
+	if isJSON(br) {
+		d := json.NewDecoder(br)
+		next = func() (entry, error) { return readJSON(d) }
+	}
+	for {
+		e, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		unit := string(e["_SYSTEMD_UNIT"])
+		if unit == "" || len(selected) > 0 && !selected[unit] {
+			continue
+		}
+		f, err := p.Get(filepath.Join(opt.Dir, filepath.Base(unit)+".log"))
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(line(e)); err != nil {
+			if _, ok := err.(*rotate.Error); !ok {
+				return err
+			}
+		}
+	}
+}
+
+// entry is a journal entry by field names.
+type entry map[string][]byte
+
+// line formats e as a single line, so that it is written at once.
+func line(e entry) []byte {
+	var b []byte
+	if us, err := strconv.ParseInt(string(e["__REALTIME_TIMESTAMP"]), 10, 64); err == nil {
+		t := time.Unix(us/1e6, us%1e6*1e3).UTC()
+		b = append(t.AppendFormat(b, TimeFormat), ' ')
+	}
+	msg := bytes.TrimRight(e["MESSAGE"], "\n")
+	return append(append(b, msg...), '\n')
+}
+
+// isJSON reports whether r starts with a JSON object.
+func isJSON(r *bufio.Reader) bool {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return false
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			_ = r.UnreadByte()
+			return c == '{'
+		}
+	}
+}
+
+// maxFieldSize is the maximum size of a binary field of export format,
+// DATA_SIZE_MAX of journald.
+const maxFieldSize = 64 << 20
+
+// readExport reads an entry of journal export format: fields are
+// KEY=value lines, binary fields are KEY, a little-endian 64-bit size and
+// data. Entries are separated by an empty line.
+func readExport(r *bufio.Reader) (entry, error) {
+	e := make(entry)
+	for {
+		b, err := r.ReadBytes('\n')
+		if err == io.EOF && len(b) == 0 {
+			if len(e) == 0 {
+				return nil, io.EOF
+			}
+			return e, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		b = bytes.TrimSuffix(b, []byte("\n"))
+		if len(b) == 0 {
+			if len(e) > 0 {
+				return e, nil
+			}
+			continue
+		}
+		if i := bytes.IndexByte(b, '='); i >= 0 {
+			e[string(b[:i])] = b[i+1:]
+			continue
+		}
+		var n uint64
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil || n > maxFieldSize {
+			return nil, ErrFormat
+		}
+		v := make([]byte, n+1) // + a newline
+		if _, err := io.ReadFull(r, v); err != nil || v[n] != '\n' {
+			return nil, ErrFormat
+		}
+		e[string(b)] = v[:n]
+	}
+}
+
+// readJSON reads an entry of journal JSON format: a value is a string,
+// an array of bytes or an array of them for repeated fields. The first
+// of repeated values is used.
+func readJSON(d *json.Decoder) (entry, error) {
+	var m map[string]json.RawMessage
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+	e := make(entry, len(m))
+	for k, raw := range m {
+		v, ok := jsonValue(raw)
+		if !ok {
+			return nil, ErrFormat
+		}
+		e[k] = v
+	}
+	return e, nil
+}
+
+func jsonValue(raw json.RawMessage) ([]byte, bool) {
+	var s *string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s == nil {
+			return nil, true
+		}
+		return []byte(*s), true
+	}
+	var a []json.RawMessage
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil, false
+	}
+	if len(a) > 0 && bytes.IndexByte([]byte(`"[n`), a[0][0]) >= 0 {
+		return jsonValue(a[0]) // repeated
+	}
+	b := make([]byte, len(a))
+	for i, v := range a {
+		n, err := strconv.ParseUint(string(v), 10, 8)
+		if err != nil {
+			return nil, false
+		}
+		b[i] = byte(n)
+	}
+	return b, true
+}
@@ -0,0 +1,101 @@
+package journald_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+	"github.com/koorgoo/rotate/journald"
+)
+
+// binaryField returns a binary field of export format.
+func binaryField(k, v string) string {
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(v)))
+	return k + "\n" + string(n[:]) + v + "\n"
+}
+
+var IngestTests = []struct {
+	Name  string
+	Input string
+	Units []string
+	Want  map[string]string
+}{
+	{
+		"export",
+		"__REALTIME_TIMESTAMP=1538395200000001\n_SYSTEMD_UNIT=a.service\nMESSAGE=1\n\n" +
+			"_SYSTEMD_UNIT=b.service\n" + binaryField("MESSAGE", "2\n3") + "\n" +
+			"MESSAGE=no unit\n\n" +
+			"_SYSTEMD_UNIT=a.service\nMESSAGE=4\n",
+		nil,
+		map[string]string{"a.service.log": "2018-10-01T12:00:00.000001Z 1\n4\n", "b.service.log": "2\n3\n"},
+	},
+	{
+		"json",
+		`{"_SYSTEMD_UNIT":"a.service","MESSAGE":"1"}` + "\n" +
+			`{"_SYSTEMD_UNIT":"b.service","MESSAGE":[50]}` + "\n" +
+			`{"_SYSTEMD_UNIT":"a.service","MESSAGE":["3","x"]}` + "\n",
+		[]string{"a.service"},
+		map[string]string{"a.service.log": "1\n3\n"},
+	},
+}
+
+func TestIngest(t *testing.T) {
+	for _, tt := range IngestTests {
+		root, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(root)
+
+		p := rotate.NewPool(rotate.Config{Count: 2})
+		err = journald.Ingest(strings.NewReader(tt.Input), p, journald.Options{Dir: root, Units: tt.Units})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.Name, err)
+		}
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+		names, err := filepath.Glob(filepath.Join(root, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != len(tt.Want) {
+			t.Errorf("%s: want %d files, got %v", tt.Name, len(tt.Want), names)
+		}
+		for name, want := range tt.Want {
+			b, err := ioutil.ReadFile(filepath.Join(root, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != want {
+				t.Errorf("%s: %s: want %q, got %q", tt.Name, name, want, b)
+			}
+		}
+	}
+}
+
+func TestIngest_fieldSize(t *testing.T) {
+	root, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, n := range []uint64{1<<64 - 1, 1 << 40} {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], n)
+		in := "_SYSTEMD_UNIT=a.service\nMESSAGE\n" + string(b[:]) + "x\n"
+
+		p := rotate.NewPool(rotate.Config{Count: 2})
+		err := journald.Ingest(strings.NewReader(in), p, journald.Options{Dir: root})
+		if err != journald.ErrFormat {
+			t.Errorf("%d: want %v, got %v", n, journald.ErrFormat, err)
+		}
+		p.Close()
+	}
+}
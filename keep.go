@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// ErrKeepNaming is returned by Wrap if Config.KeepPatterns are set
+// without Timestamp naming.
+var ErrKeepNaming = errors.New("rotate: KeepPatterns require Timestamp naming")
+
+// checkPatterns returns ErrBadPattern if any of patterns is malformed
+// and ErrKeepNaming if patterns are set without Timestamp naming.
+func checkPatterns(patterns []string, naming NamingScheme) error {
+	if len(patterns) > 0 && naming != Timestamp {
+		return ErrKeepNaming
+	}
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kept reports whether a rotated file s matches Config.KeepPatterns.
+func (r *rotator) kept(s string) bool {
+	for _, p := range r.c.KeepPatterns {
+		if ok, _ := filepath.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// spare splits victims of retention into files to remove and kept files
+// to forget.
+func (r *rotator) spare(victims []string) (remove, forget []string) {
+	for _, s := range victims {
+		if r.kept(s) {
+			forget = append(forget, s)
+		} else {
+			remove = append(remove, s)
+		}
+	}
+	return
+}
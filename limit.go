@@ -0,0 +1,78 @@
+package rotate
+
+import "time"
+
+// rateLimit allows at most max events per period.
+// A nil *rateLimit allows everything.
+type rateLimit struct {
+	period time.Duration
+	times  []time.Time // ring of last events
+	i      int         // oldest event
+}
+
+func newRateLimit(max int, period time.Duration) *rateLimit {
+	if max <= 0 {
+		return nil
+	}
+	if period <= 0 {
+		period = time.Minute
+	}
+	return &rateLimit{
+		period: period,
+		times:  make([]time.Time, max),
+	}
+}
+
+// Allow reports whether an event may happen at t.
+func (l *rateLimit) Allow(t time.Time) bool {
+	if l == nil {
+		return true
+	}
+	v := l.times[l.i]
+	return v.IsZero() || t.Sub(v) >= l.period
+}
+
+// Add records an event at t.
+func (l *rateLimit) Add(t time.Time) {
+	if l == nil {
+		return
+	}
+	l.times[l.i] = t
+	l.i = (l.i + 1) % len(l.times)
+}
+
+// bucket is a token bucket refilled by rate tokens per second.
+// Its capacity equals rate. A take larger than capacity is allowed from
+// a full bucket and leaves it in debt, which is paid off by refills.
+type bucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate int64) *bucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &bucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+	}
+}
+
+// Take takes n tokens at t if there are enough of them or the bucket is
+// full.
+func (b *bucket) Take(n int, t time.Time) bool {
+	if !b.last.IsZero() {
+		b.tokens += t.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+	}
+	b.last = t
+	if float64(n) > b.tokens && b.tokens < b.rate {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
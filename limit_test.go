@@ -0,0 +1,49 @@
+package rotate_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_dropsWritesOverBytesPerSec(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{BytesPerSec: 1 << 20})
+	defer r.Close()
+
+	for i := 0; i < 3; i++ {
+		if n := write(t, r, string(make([]byte, 1<<19))); n != 1<<19 {
+			t.Fatalf("want %d bytes, wrote %d bytes", 1<<19, n)
+		}
+	}
+
+	v, err := r.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Size() != 1<<20 {
+		t.Fatalf("want %d bytes on disk, got %d", 1<<20, v.Size())
+	}
+}
+
+func TestFile_largeWriteOverBytesPerSec(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{BytesPerSec: 1 << 20})
+	defer r.Close()
+
+	write(t, r, string(make([]byte, 2<<20))) // passes in debt
+	write(t, r, "1")                         // dropped
+
+	v, err := r.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Size() != 2<<20 {
+		t.Fatalf("want %d bytes on disk, got %d", 2<<20, v.Size())
+	}
+}
@@ -0,0 +1,81 @@
+package rotate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"time"
+)
+
+// link replaces the last rotated file with a hard link to the previous
+// one if their content is identical, see Config.LinkDuplicates.
+func (r *rotator) link() error {
+	if !r.c.LinkDuplicates || r.c.IDs || r.c.Protect == ProtectImmutable {
+		return nil
+	}
+	if len(r.names) < 3 || r.names[1] == "" || r.names[2] == "" {
+		return nil
+	}
+	s, prev := r.names[1], r.names[2]
+	ok, mtime, err := duplicate(r.abs(s), r.abs(prev))
+	if err != nil || !ok {
+		return r.linkError(s, err)
+	}
+	// A link replaces s by rename, so that s is kept as is if links are
+	// not supported or fail.
+	tmp := r.abs(s) + tmpExt
+	if err := os.Link(r.abs(prev), tmp); err != nil {
+		return r.linkError(s, err)
+	}
+	if err := rename(tmp, r.abs(s)); err != nil {
+		_ = remove(tmp)
+		return r.linkError(s, err)
+	}
+	// Files share modification time, so that the newer is not removed
+	// by Config.MaxAge before its time.
+	return r.linkError(s, os.Chtimes(r.abs(s), mtime, mtime))
+}
+
+func (r *rotator) linkError(s string, err error) error {
+	if err != nil {
+		return &Error{Filename: s, Err: err}
+	}
+	return nil
+}
+
+// duplicate reports whether files a and b are distinct files of identical
+// content and returns modification time of a.
+func duplicate(a, b string) (ok bool, mtime time.Time, err error) {
+	va, err := os.Stat(a)
+	if err != nil {
+		return
+	}
+	vb, err := os.Stat(b)
+	if err != nil || os.SameFile(va, vb) || va.Size() != vb.Size() {
+		return
+	}
+	ha, err := sum(a)
+	if err != nil {
+		return
+	}
+	hb, err := sum(b)
+	if err != nil {
+		return
+	}
+	return bytes.Equal(ha, hb), va.ModTime(), nil
+}
+
+// sum returns a SHA-256 sum of content of a file.
+func sum(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,59 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileInfo describes a file of a rotation set.
+type FileInfo struct {
+	Name       string
+	Size       int64
+	ModTime    time.Time
+	Compressed bool
+}
+
+// ListOptions paginates ListInfo.
+type ListOptions struct {
+	// Offset is a number of files to skip.
+	Offset int
+	// Limit is the maximum number of files to list.
+	// If Limit == 0, all files are listed.
+	Limit int
+}
+
+// ListInfo is like List, but describes files. Only files of a requested
+// page are stat'ed. Files removed meanwhile are skipped, so a page may be
+// shorter than Limit.
+func ListInfo(root, base string, opt ListOptions) ([]FileInfo, error) {
+	names, err := List(root, base)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Offset >= len(names) {
+		return nil, nil
+	}
+	names = names[opt.Offset:]
+	if opt.Limit > 0 && opt.Limit < len(names) {
+		names = names[:opt.Limit]
+	}
+	files := make([]FileInfo, 0, len(names))
+	for _, s := range names {
+		v, err := os.Stat(filepath.Join(root, s))
+		if os.IsNotExist(err) {
+			continue // rotated meanwhile
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, FileInfo{
+			Name:       s,
+			Size:       v.Size(),
+			ModTime:    v.ModTime(),
+			Compressed: strings.HasSuffix(s, gzipExt),
+		})
+	}
+	return files, nil
+}
@@ -0,0 +1,219 @@
+// Package logrotate parses a subset of logrotate configuration into
+// rotate.Config, easing migration from logrotate.
+//
+// Supported directives:
+//
+//     size N[k|M|G]  rotate.Config.Bytes
+//     rotate N       rotate.Config.Count (N rotated files + a current one)
+//     maxage D       rotate.Config.MaxAge (days)
+//     compress       rotate.Config.Compress
+//     nocompress
+//     copytruncate   rotate.Config.Method = rotate.CopyTruncate
+//     nocopytruncate
+//     dateext        rotate.Config.Naming = rotate.Timestamp
+//     nodateext
+//     hourly         rotate.Config.Interval = time.Hour
+//     daily          rotate.Config.Interval = 24 * time.Hour
+//     weekly         rotate.Config.Interval = 7 * 24 * time.Hour
+//
+// weekly rotates every 7 days from midnight, not on a day of a week.
+// monthly and yearly are rejected, as months and years vary in length.
+//
+// Scripts (prerotate, postrotate, etc.) are skipped, as well as other
+// directives which do not affect this package. Directives which can not be
+// honored are reported as errors.
+package logrotate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+// Entry is a block of configuration applied to files matching Paths.
+type Entry struct {
+	Paths  []string
+	Config rotate.Config
+}
+
+// Parse parses configuration from r. Global directives are defaults for
+// entries following them.
+func Parse(r io.Reader) ([]Entry, error) {
+	p := parser{s: bufio.NewScanner(r)}
+	return p.parse()
+}
+
+// unsupported are directives which change behaviour in a way this package
+// can not reproduce.
+var unsupported = map[string]bool{
+	"dateformat": true, // rotate.TimestampFormat is fixed
+	"monthly":    true, // rotate.Config.Interval is fixed
+	"yearly":     true,
+}
+
+// intervals are directives of time-based rotation.
+var intervals = map[string]time.Duration{
+	"hourly": time.Hour,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// scripts are directives followed by a script ending with endscript.
+var scripts = map[string]bool{
+	"prerotate":   true,
+	"postrotate":  true,
+	"firstaction": true,
+	"lastaction":  true,
+	"preremove":   true,
+}
+
+type parser struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (p *parser) parse() ([]Entry, error) {
+	var entries []Entry
+	var global rotate.Config
+	var cur *Entry
+	for p.next() {
+		fields := strings.Fields(p.text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "}":
+			if cur == nil {
+				return nil, p.errorf("unexpected }")
+			}
+			entries = append(entries, *cur)
+			cur = nil
+		case fields[len(fields)-1] == "{" || strings.HasSuffix(fields[len(fields)-1], "{"):
+			if cur != nil {
+				return nil, p.errorf("nested block")
+			}
+			paths := strings.Fields(strings.TrimSuffix(strings.Join(fields, " "), "{"))
+			if len(paths) == 0 {
+				return nil, p.errorf("block without paths")
+			}
+			cur = &Entry{Paths: paths, Config: global}
+		default:
+			c := &global
+			if cur != nil {
+				c = &cur.Config
+			}
+			if err := p.directive(c, fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.s.Err(); err != nil {
+		return nil, err
+	}
+	if cur != nil {
+		return nil, p.errorf("unclosed block")
+	}
+	return entries, nil
+}
+
+func (p *parser) directive(c *rotate.Config, fields []string) (err error) {
+	name := fields[0]
+	if scripts[name] {
+		return p.skipScript()
+	}
+	if unsupported[name] {
+		return p.errorf("%s is not supported", name)
+	}
+	arg := func() (string, error) {
+		if len(fields) != 2 {
+			return "", p.errorf("%s requires a single argument", name)
+		}
+		return fields[1], nil
+	}
+	if d, ok := intervals[name]; ok {
+		c.Interval = d
+		return nil
+	}
+	var s string
+	switch name {
+	case "size":
+		if s, err = arg(); err == nil {
+			c.Bytes, err = rotate.ParseBytes(s)
+		}
+	case "rotate":
+		var n int64
+		if s, err = arg(); err == nil {
+			n, err = strconv.ParseInt(s, 10, 64)
+		}
+		c.Count = n + 1
+	case "maxage":
+		var n int64
+		if s, err = arg(); err == nil {
+			n, err = strconv.ParseInt(s, 10, 64)
+		}
+		c.MaxAge = time.Duration(n) * 24 * time.Hour
+	case "compress":
+		c.Compress = true
+	case "nocompress":
+		c.Compress = false
+	case "copytruncate":
+		c.Method = rotate.CopyTruncate
+	case "nocopytruncate":
+		c.Method = rotate.Rename
+	case "dateext":
+		c.Naming = rotate.Timestamp
+	case "nodateext":
+		c.Naming = rotate.Numeric
+	}
+	if err != nil {
+		if _, ok := err.(*Error); !ok {
+			err = p.errorf("%s: %v", name, err)
+		}
+	}
+	return
+}
+
+func (p *parser) skipScript() error {
+	for p.next() {
+		if strings.TrimSpace(p.text()) == "endscript" {
+			return nil
+		}
+	}
+	return p.errorf("script without endscript")
+}
+
+func (p *parser) next() bool {
+	ok := p.s.Scan()
+	if ok {
+		p.line++
+	}
+	return ok
+}
+
+// text returns a current line without a comment.
+func (p *parser) text() string {
+	s := p.s.Text()
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// Error is a parsing error.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("logrotate: line %d: %s", e.Line, e.Msg)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,77 @@
+package logrotate_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+	"github.com/koorgoo/rotate/logrotate"
+)
+
+const config = `
+# defaults
+compress
+
+/var/log/app/*.log /var/log/app.log {
+    size 100M
+    daily
+    rotate 5
+    maxage 7
+    missingok
+    postrotate
+        kill -HUP $(cat /run/app.pid)
+    endscript
+}
+
+/var/log/other.log {
+    nocompress
+    copytruncate
+    dateext
+}
+`
+
+func TestParse(t *testing.T) {
+	entries, err := logrotate.Parse(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []logrotate.Entry{
+		{
+			Paths: []string{"/var/log/app/*.log", "/var/log/app.log"},
+			Config: rotate.Config{
+				Bytes:    100 * rotate.MB,
+				Interval: 24 * time.Hour,
+				Count:    6,
+				MaxAge:   7 * 24 * time.Hour,
+				Compress: true,
+			},
+		},
+		{
+			Paths:  []string{"/var/log/other.log"},
+			Config: rotate.Config{Method: rotate.CopyTruncate, Naming: rotate.Timestamp},
+		},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("want %+v, got %+v", want, entries)
+	}
+}
+
+var ParseErrorTests = []string{
+	"/a {\n dateformat -%Y%m%d\n}",
+	"/a {\n monthly\n}",
+	"/a {\n size x\n}",
+	"/a {\n rotate\n}",
+	"/a {\n",
+	"}",
+	"/a {\n postrotate\n",
+}
+
+func TestParse_error(t *testing.T) {
+	for _, s := range ParseErrorTests {
+		if _, err := logrotate.Parse(strings.NewReader(s)); err == nil {
+			t.Errorf("%q: want error", s)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lumberjackFormat is a time format of backups made by
+// gopkg.in/natefinch/lumberjack.
+const lumberjackFormat = "2006-01-02T15-04-05.000"
+
+// listLumberjack returns names of lumberjack backups of name in root from
+// the newest to the oldest, e.g. for app.log:
+//
+//	app-2018-10-01T15-04-05.000.log
+//	app-2018-10-01T12-00-00.000.log.gz
+func listLumberjack(root, name string) ([]string, error) {
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+
+	d, err := os.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	all, err := d.Readdirnames(-1)
+	_ = d.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	times := make(map[string]time.Time)
+	for _, s := range all {
+		if !strings.HasPrefix(s, prefix) {
+			continue
+		}
+		v := strings.TrimSuffix(s, gzipExt)
+		if !strings.HasSuffix(v, ext) {
+			continue
+		}
+		v = strings.TrimSuffix(strings.TrimPrefix(v, prefix), ext)
+		t, err := time.Parse(lumberjackFormat, v)
+		if err != nil {
+			continue
+		}
+		names = append(names, s)
+		times[s] = t
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return times[names[i]].After(times[names[j]])
+	})
+	return names, nil
+}
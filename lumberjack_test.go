@@ -0,0 +1,34 @@
+// +build linux
+
+package rotate_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_lumberjackBackupsCount(t *testing.T) {
+	root := touch(t,
+		"app.log",
+		"app-2018-10-01T10-00-00.000.log",
+		"app-2018-10-01T11-00-00.000.log.gz",
+		"app-2018-10-01T12-00-00.000.log",
+		"app-notatime.log",
+	)
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "app.log", rotate.Config{Bytes: 1, Count: 4, Lumberjack: true})
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "1")
+
+	exist(t, root, "app.log.1")
+	exist(t, root, "app-2018-10-01T12-00-00.000.log")
+	exist(t, root, "app-2018-10-01T11-00-00.000.log.gz")
+	notExist(t, root, "app-2018-10-01T10-00-00.000.log")
+	exist(t, root, "app-notatime.log")
+}
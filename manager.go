@@ -0,0 +1,170 @@
+package rotate
+
+import (
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Manager enforces a disk budget shared by files opened with it, e.g. by
+// an agent keeping dozens of per-service files on one volume.
+//
+// When current and rotated files exceed the budget, rotated files are
+// pruned from the oldest. Files with the lowest Config.Priority give up
+// the excess first, every file a part proportional to a size of its
+// rotated files; files of a higher priority are pruned only for what is
+// left. Current files are never pruned. A budget is enforced after each
+// rotation of a managed file and on Enforce.
+type Manager struct {
+	budget    int64
+	enforcing sync.Mutex
+	mu        sync.Mutex
+	handles   []File
+	files     []*file
+	closed    bool
+}
+
+// NewManager returns Manager keeping files within budget bytes.
+func NewManager(budget int64) *Manager {
+	return &Manager{budget: budget}
+}
+
+// Open opens a file with Open and manages it. A file which is not rotated
+// on a current system is returned with ErrNotSupported and not managed.
+func (m *Manager) Open(name string, c Config) (*Rotor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, os.ErrClosed
+	}
+	onRotate := c.OnRotate
+	c.OnRotate = func(v RotationInfo) {
+		if onRotate != nil {
+			onRotate(v)
+		}
+		// Errors are emitted with PruneFailed event.
+		_ = m.Enforce()
+	}
+	h, err := Open(name, c)
+	if err != nil {
+		return h, err
+	}
+	m.handles = append(m.handles, h)
+	if f, ok := unwrap(h); ok && !m.manages(f) {
+		m.files = append(m.files, f)
+	}
+	return h, nil
+}
+
+// manages reports whether f is managed. Files opened for the same path
+// share *file, which must be locked once by Enforce.
+func (m *Manager) manages(f *file) bool {
+	for _, v := range m.files {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// measurer is implemented by rotators which can re-calculate a size of
+// rotated files on disk.
+type measurer interface {
+	pruner
+	// Measure re-calculates and returns a total size of rotated files.
+	Measure() int64
+	// PruneBudget is Prune for a budget of Manager.
+	PruneBudget(max int64) error
+}
+
+func (r *rotator) Measure() int64 {
+	r.usage()
+	return r.used
+}
+
+func (r *rotator) PruneBudget(max int64) error { return r.prune(max, byBudget) }
+
+// Enforce prunes rotated files of managed files to fit the budget.
+// Errors are aggregated.
+func (m *Manager) Enforce() error {
+	m.enforcing.Lock()
+	defer m.enforcing.Unlock()
+	m.mu.Lock()
+	files := append([]*file(nil), m.files...)
+	m.mu.Unlock()
+
+	type member struct {
+		f       *file
+		p       measurer
+		rotated int64
+	}
+	var members []member
+	var total, rotated int64
+	// All files are locked until pruning ends, so that a budget is not
+	// exceeded meanwhile. Only Enforce locks several files and it is
+	// serialized, so it does not deadlock.
+	for _, f := range files {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.closed {
+			continue
+		}
+		total += f.size()
+		p, ok := f.r.(measurer)
+		if !ok {
+			continue
+		}
+		n := p.Measure()
+		members = append(members, member{f, p, n})
+		total += n
+		rotated += n
+	}
+	excess := total - m.budget
+	if excess <= 0 || rotated == 0 {
+		return nil
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].f.c.Priority < members[j].f.c.Priority
+	})
+	var errs errorList
+	for i := 0; i < len(members) && excess > 0; {
+		// A class of files with equal priority.
+		j, rotated := i, int64(0)
+		for ; j < len(members) && members[j].f.c.Priority == members[i].f.c.Priority; j++ {
+			rotated += members[j].rotated
+		}
+		share := excess
+		if share > rotated {
+			share = rotated
+		}
+		for _, v := range members[i:j] {
+			if v.rotated == 0 {
+				continue
+			}
+			cut := int64(math.Ceil(float64(share) * float64(v.rotated) / float64(rotated)))
+			if err := v.p.PruneBudget(v.rotated - cut); err != nil {
+				v.f.emit(Event{Type: PruneFailed, Filename: v.f.w.Name(), Err: err})
+				errs = append(errs, err)
+			}
+			excess -= v.rotated - v.p.Rotated()
+		}
+		i = j
+	}
+	return errs.err()
+}
+
+// Close closes all files opened with m and returns the first error.
+// Files already closed by a caller are skipped.
+func (m *Manager) Close() (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for _, h := range m.handles {
+		if cerr := h.Close(); err == nil && cerr != os.ErrClosed {
+			err = cerr
+		}
+	}
+	m.handles, m.files = nil, nil
+	return
+}
@@ -0,0 +1,83 @@
+// +build linux
+
+package rotate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestManager(t *testing.T) {
+	root := touch(t, "a", "b")
+	defer os.RemoveAll(root)
+
+	m := rotate.NewManager(8)
+	c := rotate.Config{Bytes: 2, Count: 5}
+	a, err := m.Open(filepath.Join(root, "a"), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := m.Open(filepath.Join(root, "b"), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		write(t, a, "12")
+	}
+	exist(t, root, "a.3") // a (2) + rotated (6) fit
+
+	write(t, b, "12")
+	write(t, b, "12")
+	// An excess of 4 bytes is shared by rotated files of a (6) and b (2)
+	// as 3 and 1 bytes, rounded to whole files.
+	exist(t, root, "a.1")
+	notExist(t, root, "a.2")
+	notExist(t, root, "a.3")
+	notExist(t, root, "b.1")
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Open(filepath.Join(root, "a"), c); err != os.ErrClosed {
+		t.Fatalf("want %v, got %v", os.ErrClosed, err)
+	}
+}
+
+func TestManager_priority(t *testing.T) {
+	root := touch(t, "audit", "debug")
+	defer os.RemoveAll(root)
+
+	m := rotate.NewManager(8)
+	defer m.Close()
+	audit, err := m.Open(filepath.Join(root, "audit"), rotate.Config{Bytes: 2, Count: 5, Priority: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	debug, err := m.Open(filepath.Join(root, "debug"), rotate.Config{Bytes: 2, Count: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		write(t, audit, "12")
+	}
+	write(t, debug, "12")
+	write(t, debug, "12")
+	// An excess of 2 bytes is taken from debug only.
+	exist(t, root, "audit.2")
+	notExist(t, root, "debug.1")
+
+	write(t, debug, "12")
+	write(t, audit, "12")
+	// debug has nothing to prune, so audit gives up its oldest file.
+	exist(t, root, "audit.2")
+	notExist(t, root, "audit.3")
+	notExist(t, root, "debug.1")
+}
@@ -0,0 +1,159 @@
+package rotate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// manifestExt is an extension of a manifest file, see Config.Manifest.
+const manifestExt = ".manifest"
+
+// manifest is persisted metadata of a rotation set.
+type manifest struct {
+	// Generation is a generation of the last sealed file.
+	Generation int64 `json:"generation"`
+	// Files are sealed files by name without a compression extension,
+	// see genKey.
+	Files map[string]entry `json:"files,omitempty"`
+}
+
+// entry is a sealed file recorded in a manifest.
+type entry struct {
+	Generation int64 `json:"generation"`
+	// First and Last are times of the first and the last write to a file,
+	// see RotationInfo.
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// genKey returns a key of a rotated file s in manifest.Files, which does
+// not change on compression.
+func genKey(s string) string {
+	return strings.TrimSuffix(s, gzipExt)
+}
+
+// lookup returns a name of names with generation or "" if there is none.
+func (m *manifest) lookup(names []string, generation int64) string {
+	for _, s := range names {
+		if e, ok := m.Files[genKey(s)]; ok && e.Generation == generation {
+			return s
+		}
+	}
+	return ""
+}
+
+// move moves a generation of a rotated file renamed from one name to
+// another. It does nothing if to is "", i.e. a file is removed.
+func (m *manifest) move(from, to string) {
+	e, ok := m.Files[genKey(from)]
+	if !ok {
+		return
+	}
+	delete(m.Files, genKey(from))
+	if to != "" {
+		m.Files[genKey(to)] = e
+	}
+}
+
+// manifestName returns a name of a manifest file for base.
+func manifestName(base string) string {
+	return "." + base + manifestExt
+}
+
+// loadManifest reads a manifest at name. A missing manifest is empty.
+func loadManifest(name string) (*manifest, error) {
+	m := new(manifest)
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// save writes m to name through a temporary file, so that a manifest is
+// never seen partially written.
+func (m *manifest) save(name string, mode os.FileMode) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp := name + tmpExt
+	f, err := openFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = rename(tmp, name)
+	}
+	if err != nil {
+		_ = remove(tmp)
+	}
+	return err
+}
+
+// spanner is implemented by rotators which record times of writes to
+// sealed files.
+type spanner interface {
+	// span sets times of the first and the last write to a current file
+	// before it is sealed.
+	span(first, last time.Time)
+}
+
+func (r *rotator) span(first, last time.Time) { r.first, r.last = first, last }
+
+// generationer is implemented by rotators which number sealed files.
+type generationer interface {
+	// Generation returns a generation of the last sealed file.
+	Generation() int64
+}
+
+// moveGeneration records in a manifest, if any, that a rotated file is
+// renamed. A removed file is moved to "".
+func (r *rotator) moveGeneration(from, to string) {
+	if r.manifest != nil {
+		r.manifest.move(from, to)
+	}
+}
+
+func (r *rotator) Generation() int64 {
+	if r.manifest == nil {
+		return 0
+	}
+	return r.manifest.Generation
+}
+
+// seal records a next generation of the last rotated file in a manifest.
+func (r *rotator) seal() error {
+	if r.manifest == nil {
+		return nil
+	}
+	r.manifest.Generation++
+	if len(r.names) > 1 && r.names[1] != "" {
+		if r.manifest.Files == nil {
+			r.manifest.Files = make(map[string]entry)
+		}
+		r.manifest.Files[genKey(r.names[1])] = entry{
+			Generation: r.manifest.Generation,
+			First:      r.first,
+			Last:       r.last,
+		}
+	}
+	s := manifestName(r.name)
+	if err := r.manifest.save(r.abs(s), r.mode.Perm()); err != nil {
+		return &Error{Filename: s, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package rotate
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// continued reports whether a line starting with c continues a record,
+// see Config.Multiline.
+func continued(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// lastGroup returns an index of the last record start in b after 0 and
+// not after max, or -1. A record starts at a line which is not continued.
+func lastGroup(b []byte, max int) int {
+	if max >= len(b) {
+		max = len(b) - 1
+	}
+	for p := max; p > 0; p-- {
+		if b[p-1] == '\n' && !continued(b[p]) {
+			return p
+		}
+	}
+	return -1
+}
+
+// nextGroup returns an index of the first record start in b after min
+// or -1.
+func nextGroup(b []byte, min int) int {
+	for p := min + 1; p < len(b); p++ {
+		if b[p-1] == '\n' && !continued(b[p]) {
+			return p
+		}
+	}
+	return -1
+}
+
+// writeGroups writes complete multi-line records of b and holds the last
+// one until a next record starts. See Config.Multiline.
+func (f *file) writeGroups(b []byte) (int, error) {
+	held := len(f.partial)
+	data := append(f.partial, b...)
+	p := lastGroup(data, len(data))
+	if p < 0 {
+		f.partial = data
+		return len(b), nil
+	}
+	msg := data[:p]
+	f.partial = nil
+	n, err := f.message(msg)
+	if n < len(msg) {
+		if n -= held; n < 0 {
+			n = 0
+		}
+		return n, err
+	}
+	f.partial = append([]byte(nil), data[p:]...)
+	return len(b), err
+}
+
+// Flush writes a run of duplicates of Config.Dedup and a record held by
+// Config.Multiline and waits for compression of Config.Compress.
+func (f *file) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.flush()
+}
+
+// flush is Flush under the lock. An incomplete record of Config.JSONLines
+// is held, as it is not valid yet.
+func (f *file) flush() error {
+	err := f.flushDedup()
+	if err == nil && f.c.Multiline && !f.c.JSONLines {
+		err = f.flushRecords()
+	}
+	if v, ok := f.r.(waiter); ok && err == nil {
+		err = v.wait()
+	}
+	return err
+}
+
+// Groups reads multi-line records written with Config.Multiline from
+// a rotation set, from the oldest rotated file to a current one.
+// Compressed files are decompressed.
+//
+// All files are opened by NewGroups, so that rotation during read does not
+// affect it.
+type Groups struct {
+	files []*os.File
+	cur   *bufio.Reader // reader of files[0]
+	next  []byte        // the first line of a next record
+}
+
+// NewGroups returns Groups for a rotation set of a file with name.
+func NewGroups(name string) (*Groups, error) {
+	files, err := openSet(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Groups{files: files}, nil
+}
+
+// Next returns a next record with continuation lines or io.EOF if no
+// records are left.
+func (g *Groups) Next() ([]byte, error) {
+	for len(g.files) > 0 {
+		if g.cur == nil {
+			z, err := decompress(g.files[0])
+			if err != nil {
+				return nil, err
+			}
+			g.cur = bufio.NewReader(z)
+		}
+		record := g.next
+		g.next = nil
+		for {
+			line, err := g.cur.ReadBytes('\n')
+			if len(line) > 0 {
+				if len(record) > 0 && !continued(line[0]) {
+					g.next = line
+					return record, nil
+				}
+				record = append(record, line...)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		g.cur = nil
+		_ = g.files[0].Close()
+		g.files = g.files[1:]
+		if len(record) > 0 {
+			return record, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// Close closes files left to read.
+func (g *Groups) Close() error {
+	err := closeAll(g.files)
+	g.files = nil
+	return err
+}
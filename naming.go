@@ -0,0 +1,262 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// NamingScheme defines names of rotated files.
+type NamingScheme int
+
+// Naming schemes.
+const (
+	// Numeric names rotated files with a counter: app.log.1, app.log.2.
+	// Files are renamed on each rotation.
+	Numeric NamingScheme = iota
+	// ZeroPadded is like Numeric, but a counter is padded with zeros to
+	// 3 digits, so that names sort well: app.log.001, app.log.002.
+	ZeroPadded
+	// Timestamp names rotated files with time of rotation formatted with
+	// TimestampFormat: app.log.20181001T150405. Rotated files are never
+	// renamed.
+	Timestamp
+)
+
+// TimestampFormat is a time format of Timestamp naming scheme.
+const TimestampFormat = "20060102T150405"
+
+// padWidth is a width of a counter of ZeroPadded naming scheme.
+const padWidth = 3
+
+var namingSchemes = map[NamingScheme]string{
+	Numeric:    "numeric",
+	ZeroPadded: "zero-padded",
+	Timestamp:  "timestamp",
+}
+
+func (s NamingScheme) String() string {
+	if v, ok := namingSchemes[s]; ok {
+		return v
+	}
+	return fmt.Sprintf("NamingScheme(%d)", int(s))
+}
+
+// Set sets s from a name returned by String. It implements flag.Value.
+func (s *NamingScheme) Set(name string) error {
+	for k, v := range namingSchemes {
+		if v == name {
+			*s = k
+			return nil
+		}
+	}
+	return fmt.Errorf("rotate: unknown naming scheme %q", name)
+}
+
+// format returns a name of a rotated file base with counter n rotated at t.
+func (s NamingScheme) format(base string, n int64, t time.Time, ext string) string {
+	switch s {
+	case ZeroPadded:
+		return fmt.Sprintf("%s.%0*d%s", base, padWidth, n, ext)
+	case Timestamp:
+		if n > 0 {
+			return fmt.Sprintf("%s.%s-%d%s", base, t.Format(TimestampFormat), n, ext)
+		}
+		return base + "." + t.Format(TimestampFormat) + ext
+	}
+	return fmt.Sprintf("%s.%d%s", base, n, ext)
+}
+
+// Name is a name of a file of a rotation set parsed by ParseName.
+type Name struct {
+	// Base is a name of a current file.
+	Base string
+	// N is a rotation counter of Numeric and ZeroPadded schemes.
+	N int64
+	// Time is time of rotation of Timestamp scheme in a location of
+	// ParseNameIn.
+	Time time.Time
+	// Seq disambiguates Timestamp names rotated within the same second:
+	// app.log.20181001T150405-1.
+	Seq int64
+	// Ext is a compression extension, e.g. ".gz".
+	Ext string
+	// Valid reports whether name has a rotation suffix. Otherwise Base
+	// equals name.
+	Valid bool
+}
+
+// ParseName parses name of a rotated file in local time, like
+// ParseNameIn(name, time.Local).
+func ParseName(name string) Name {
+	return ParseNameIn(name, time.Local)
+}
+
+// ParseNameIn parses name of a rotated file. Unlike Split, it describes
+// names of all naming schemes. A timestamp is parsed in loc, which is
+// time.UTC for names of Config.UseUTC.
+func ParseNameIn(name string, loc *time.Location) Name {
+	var v Name
+	var t time.Time
+	v.Base, v.N, t, v.Ext = parse(name)
+	v.Valid = v.Base != name
+	if !t.IsZero() {
+		v.N, v.Seq = 0, v.N
+		y, m, d := t.Date()
+		v.Time = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+	}
+	return v
+}
+
+// newer reports whether a rotation set file a is newer than b:
+// a current file, then counters, then timestamps from the newest.
+// Of equal timestamps, a greater disambiguator is newer.
+func newer(a, b string) bool {
+	_, an, at, _ := parse(a)
+	_, bn, bt, _ := parse(b)
+	switch {
+	case at.IsZero() && bt.IsZero():
+		return an < bn
+	case at.Equal(bt):
+		return an > bn
+	case at.IsZero():
+		return true
+	case bt.IsZero():
+		return false
+	}
+	return at.After(bt)
+}
+
+// stamp renames a current file to a timestamped name.
+func (r *rotator) stamp() error {
+	if len(r.names) < 2 {
+		return nil // removed
+	}
+	s := r.stampName(r.c.now())
+	op := rename
+	if r.c.Method == CopyTruncate {
+		op = r.copy
+	}
+	if err := op(r.abs(r.names[0]), r.abs(s)); err != nil {
+		return &Error{Filename: r.names[0], Err: err}
+	}
+	copy(r.names[2:], r.names[1:len(r.names)-1])
+	r.names[1] = s
+	return nil
+}
+
+// stampName returns a free Timestamp name of a file rotated at t.
+// Rotations within the same second are disambiguated with a suffix -1, -2
+// and so on.
+func (r *rotator) stampName(t time.Time) string {
+	for n := int64(0); ; n++ {
+		s := Timestamp.format(r.name, n, t, "")
+		if !r.taken(s) {
+			return s
+		}
+	}
+}
+
+// taken reports whether a rotated file s exists, possibly compressed.
+func (r *rotator) taken(s string) bool {
+	for _, ext := range []string{"", gzipExt} {
+		if _, err := os.Lstat(r.abs(s + ext)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Migrate renames rotated files of a file base in root from one naming
+// scheme to another preserving their order. Timestamps are taken from
+// modification time of files.
+//
+// Files named with other schemes are left intact. Migrate fails before any
+// rename if a target name exists, except for Timestamp names, which are
+// disambiguated with a suffix like names of rotation within the same
+// second. A manifest, if any, follows renames.
+func Migrate(root, base string, from, to NamingScheme) error {
+	if from == to {
+		return nil
+	}
+	base = filepath.Base(base)
+	names, err := List(root, base)
+	if err != nil {
+		return err
+	}
+
+	type move struct {
+		from, to string
+		n        int64     // position in a rotation set
+		t        time.Time // of a Timestamp name
+		ext      string
+	}
+	var moves []move
+	var n int64
+	for _, s := range names {
+		b, i, t, ext := parse(s)
+		if b != base || s == base {
+			continue
+		}
+		if !t.IsZero() || i > 0 {
+			n++ // position in a rotation set
+		}
+		if from.format(base, i, t, ext) != s {
+			continue
+		}
+		if to == Timestamp {
+			v, err := os.Stat(filepath.Join(root, s))
+			if err != nil {
+				return err
+			}
+			t = v.ModTime()
+		}
+		moves = append(moves, move{from: s, n: n, t: t, ext: ext})
+	}
+
+	targets := make(map[string]bool) // by genKey
+	taken := func(d string) bool {
+		for _, ext := range []string{"", gzipExt} {
+			if _, err := os.Lstat(filepath.Join(root, genKey(d)+ext)); err == nil {
+				return true
+			}
+		}
+		return targets[genKey(d)]
+	}
+	for k := range moves {
+		v := &moves[k]
+		pos := v.n
+		if to == Timestamp {
+			// Files of the same second are disambiguated like stampName
+			// does, from the oldest one, so that order is preserved.
+			v = &moves[len(moves)-1-k]
+			pos = 0
+			for taken(to.format(base, pos, v.t, v.ext)) {
+				pos++
+			}
+		}
+		v.to = to.format(base, pos, v.t, v.ext)
+		if taken(v.to) {
+			return &Error{Filename: v.to, Err: os.ErrExist}
+		}
+		targets[genKey(v.to)] = true
+	}
+	for _, m := range moves {
+		if err := rename(filepath.Join(root, m.from), filepath.Join(root, m.to)); err != nil {
+			return err
+		}
+	}
+	mname := filepath.Join(root, manifestName(base))
+	if _, err := os.Stat(mname); err != nil || len(moves) == 0 {
+		return nil
+	}
+	m, err := loadManifest(mname)
+	if err != nil {
+		return err
+	}
+	for _, v := range moves {
+		m.move(v.from, v.to)
+	}
+	return m.save(mname, 0644)
+}
@@ -0,0 +1,167 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestMigrate(t *testing.T) {
+	root := touch(t, "a", "a.1", "a.2.gz", "b.1")
+	defer os.RemoveAll(root)
+
+	if err := rotate.Migrate(root, "a", rotate.Numeric, rotate.ZeroPadded); err != nil {
+		t.Fatal(err)
+	}
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "a.001", "a.002.gz"}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("want %v, got %v", want, v)
+	}
+	exist(t, root, "b.1")
+}
+
+func TestList_disambiguated(t *testing.T) {
+	root := touch(t, "a", "a.20181001T120000", "a.20181001T120000-1", "a.20181001T120000-2.gz", "a.20181001T110000")
+	defer os.RemoveAll(root)
+
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "a.20181001T120000-2.gz", "a.20181001T120000-1", "a.20181001T120000", "a.20181001T110000"}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("want %v, got %v", want, v)
+	}
+}
+
+func TestMigrate_timestamp(t *testing.T) {
+	root := touch(t, "a", "a.1", "a.2")
+	defer os.RemoveAll(root)
+
+	t1 := time.Date(2018, 10, 1, 12, 0, 0, 0, time.Local)
+	t2 := t1.Add(-time.Hour)
+	if err := os.Chtimes(root+"/a.1", t1, t1); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(root+"/a.2", t2, t2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotate.Migrate(root, "a", rotate.Numeric, rotate.Timestamp); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "a.20181001T120000", "a.20181001T110000"}
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("want %v, got %v", want, v)
+	}
+
+	// and back
+	if err := rotate.Migrate(root, "a", rotate.Timestamp, rotate.Numeric); err != nil {
+		t.Fatal(err)
+	}
+	exist(t, root, "a.1")
+	exist(t, root, "a.2")
+}
+
+func TestMigrate_sameSecond(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	fill(t, root, map[string]string{"a": "", "a.1": "1", "a.2": "2", "a.3": "3"})
+	mtime := time.Date(2018, 10, 1, 12, 0, 0, 0, time.Local)
+	for _, name := range []string{"a.1", "a.2", "a.3"} {
+		if err := os.Chtimes(filepath.Join(root, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := rotate.Migrate(root, "a", rotate.Numeric, rotate.Timestamp); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "a.20181001T120000-2", "a.20181001T120000-1", "a.20181001T120000"}
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("want %v, got %v", want, v)
+	}
+	// The newest file keeps the newest name.
+	if b, _ := ioutil.ReadFile(filepath.Join(root, want[1])); string(b) != "1" {
+		t.Errorf("want a.1 renamed to %s, got %q", want[1], b)
+	}
+}
+
+var ParseNameTests = []struct {
+	Name   string
+	Result rotate.Name
+}{
+	{"a", rotate.Name{Base: "a"}},
+	{"a.1", rotate.Name{Base: "a", N: 1, Valid: true}},
+	{"a.002.gz", rotate.Name{Base: "a", N: 2, Ext: ".gz", Valid: true}},
+	{"a.20181001T120000", rotate.Name{
+		Base:  "a",
+		Time:  time.Date(2018, 10, 1, 12, 0, 0, 0, time.Local),
+		Valid: true,
+	}},
+	{"a.20181001T120000-2.gz", rotate.Name{
+		Base:  "a",
+		Time:  time.Date(2018, 10, 1, 12, 0, 0, 0, time.Local),
+		Seq:   2,
+		Ext:   ".gz",
+		Valid: true,
+	}},
+	{"a.20181301T120000", rotate.Name{Base: "a.20181301T120000"}},
+	{"a.20181001T120000-0", rotate.Name{Base: "a.20181001T120000-0"}},
+}
+
+func TestParseName(t *testing.T) {
+	for _, tt := range ParseNameTests {
+		if v := rotate.ParseName(tt.Name); !reflect.DeepEqual(v, tt.Result) {
+			t.Errorf("%s: want %+v, got %+v", tt.Name, tt.Result, v)
+		}
+	}
+}
+
+func TestParseNameIn(t *testing.T) {
+	v := rotate.ParseNameIn("a.20181001T120000-1", time.UTC)
+	want := rotate.Name{Base: "a", Time: time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC), Seq: 1, Valid: true}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("want %+v, got %+v", want, v)
+	}
+}
+
+func TestList_daylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer func(v *time.Location) { time.Local = v }(time.Local)
+	time.Local = loc
+
+	// 02:30 does not exist in local time of the day, e.g. with UseUTC.
+	root := touch(t, "a.20190310T014500", "a.20190310T023000")
+	defer os.RemoveAll(root)
+
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.20190310T023000", "a.20190310T014500"}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("want %v, got %v", want, v)
+	}
+}
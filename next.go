@@ -0,0 +1,52 @@
+package rotate
+
+import "time"
+
+// NextRotation returns time when f is expected to be rotated next and
+// a reason: "size" or "interval". A time of size-triggered rotation is
+// estimated from a write rate of a current file. Rotation is deferred
+// past Config.Blackout. ok is false if f is not rotated by size or
+// interval or there is no data to estimate yet.
+//
+// Rotation happens on the first write after the returned time, so it is
+// a lower bound. f must be returned by Wrap or Open.
+func NextRotation(f File) (t time.Time, reason string, ok bool) {
+	ff, ok := unwrap(f)
+	if !ok {
+		return
+	}
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	t, reason, ok = ff.next(ff.c.now())
+	if ok {
+		t = afterWindows(ff.c.Blackout, t)
+	}
+	return
+}
+
+// next is NextRotation at t before Config.Blackout.
+func (f *file) next(t time.Time) (next time.Time, reason string, ok bool) {
+	if f.c.Bytes > 0 {
+		switch {
+		case f.counted >= f.c.Bytes:
+			next, reason, ok = t, "size", true
+		case !f.first.IsZero() && f.last.After(f.first) && f.counted > 0:
+			d := f.last.Sub(f.first)
+			left := time.Duration(float64(d) * float64(f.c.Bytes-f.counted) / float64(f.counted))
+			next, reason, ok = f.last.Add(left), "size", true
+		}
+	}
+	if f.c.Interval > 0 {
+		start := f.first
+		if start.IsZero() {
+			start = f.last
+		}
+		if start.IsZero() {
+			start = t // a boundary passed while a file is empty does not count
+		}
+		if v := f.boundary(start); !ok || v.Before(next) {
+			next, reason, ok = v, "interval", true
+		}
+	}
+	return
+}
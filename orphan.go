@@ -0,0 +1,78 @@
+package rotate
+
+import (
+	"os"
+	"strings"
+)
+
+// cleaner is implemented by rotators which can remove artifacts of
+// crashed runs.
+type cleaner interface {
+	// CleanOrphans removes orphans and reports each of them to fn.
+	CleanOrphans(fn func(name string, err error)) error
+}
+
+// CleanOrphans removes temporary files of interrupted compression,
+// Config.LinkDuplicates, Config.Manifest and Config.Promote, stale
+// directory probes and empty duplicates of rotated files, e.g. a.1 next
+// to a.1.gz. A rotation set is re-read then.
+func (r *rotator) CleanOrphans(fn func(name string, err error)) error {
+	d, err := os.Open(r.root)
+	if err != nil {
+		return err
+	}
+	entries, err := d.Readdirnames(-1)
+	_ = d.Close()
+	if err != nil {
+		return err
+	}
+	re, err := toRegexp(r.name)
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(entries))
+	for _, s := range entries {
+		exists[s] = true
+	}
+	var orphans []string
+	for _, s := range entries {
+		switch {
+		case s == r.name+tmpExt, s == manifestName(r.name)+tmpExt:
+			orphans = append(orphans, s)
+		case isProbe(r.name, s):
+			if v, err := os.Lstat(r.abs(s)); err == nil && v.ModTime().Before(now().Add(-probeAge)) {
+				orphans = append(orphans, s)
+			}
+		case strings.HasSuffix(s, tmpExt):
+			if v := strings.TrimSuffix(s, tmpExt); v != r.name && re.MatchString(v) {
+				orphans = append(orphans, s)
+			}
+		case s != r.name && re.MatchString(s) && !strings.HasSuffix(s, gzipExt) && exists[s+gzipExt]:
+			if v := r.emptyOf(s, s+gzipExt); v != "" {
+				orphans = append(orphans, v)
+			}
+		}
+	}
+	for _, s := range orphans {
+		err := remove(r.abs(s))
+		if os.IsNotExist(err) {
+			continue
+		}
+		fn(r.abs(s), err)
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+	return r.Rescan()
+}
+
+// emptyOf returns an empty file of duplicates a and b, preferring b.
+// If none is empty, "" is returned.
+func (r *rotator) emptyOf(a, b string) string {
+	for _, s := range []string{b, a} {
+		if v, err := os.Stat(r.abs(s)); err == nil && v.Size() == 0 {
+			return s
+		}
+	}
+	return ""
+}
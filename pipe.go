@@ -0,0 +1,138 @@
+package rotate
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNotPipe is returned by ServePipe if a path exists, but is not a named
+// pipe.
+var ErrNotPipe = errors.New("rotate: not a named pipe")
+
+// PipeServer copies data written to a named pipe to a rotated file, so
+// that programs which can only write to a fixed path get rotation without
+// being modified.
+type PipeServer struct {
+	p       *os.File
+	f       *Rotor
+	name    string
+	created bool
+	done    chan struct{}
+	mu      sync.Mutex
+	err     error
+}
+
+// ServePipe creates a named pipe at name unless it exists and copies lines
+// written to it to a file out opened with Open and c until Close.
+// Writers may come and go: the pipe is kept open for reading.
+//
+// ErrNotPipe is returned if name exists and is not a named pipe.
+// ErrNotSupported is returned on Windows.
+func ServePipe(name, out string, c Config) (*PipeServer, error) {
+	s := &PipeServer{name: name, done: make(chan struct{})}
+	fi, err := os.Stat(name)
+	switch {
+	case os.IsNotExist(err):
+		if err := mkfifo(name, c.perm()); err != nil {
+			return nil, err
+		}
+		s.created = true
+	case err != nil:
+		return nil, err
+	case fi.Mode()&os.ModeNamedPipe == 0:
+		return nil, ErrNotPipe
+	}
+	// O_RDWR keeps a writer, so that a read does not end when the last
+	// writer closes the pipe and an open does not block.
+	p, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err == nil {
+		s.p = p
+		s.f, err = Open(out, c)
+		if err == ErrNotSupported {
+			err = nil
+		}
+	}
+	if err != nil {
+		if s.p != nil {
+			_ = s.p.Close()
+		}
+		if s.created {
+			_ = os.Remove(name)
+		}
+		return nil, err
+	}
+	go s.serve()
+	return s, nil
+}
+
+// serve copies lines from a pipe. A single write per line keeps lines
+// whole across rotations.
+func (s *PipeServer) serve() {
+	defer close(s.done)
+	r := bufio.NewReader(s.p)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, werr := s.f.Write(line); werr != nil {
+				if _, ok := werr.(*Error); !ok {
+					s.fail(werr)
+					return
+				}
+			}
+		}
+		if err != nil {
+			if !os.IsTimeout(err) && !readClosed(err) {
+				s.fail(err)
+			}
+			return
+		}
+	}
+}
+
+func (s *PipeServer) fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// readClosed reports whether err is returned by a read from a closed file.
+func readClosed(err error) bool {
+	if v, ok := err.(*os.PathError); ok {
+		err = v.Err
+	}
+	return err == os.ErrClosed || err == io.EOF
+}
+
+// pipeDrain is a time a pipe is read on Close after it is drained.
+const pipeDrain = 50 * time.Millisecond
+
+// Close copies data left in the pipe, closes the file and removes the pipe
+// if it was created by ServePipe. An error which stopped copying is
+// returned.
+func (s *PipeServer) Close() error {
+	// A deadline ends reading once the pipe is drained.
+	if err := s.p.SetReadDeadline(time.Now().Add(pipeDrain)); err != nil {
+		_ = s.p.Close()
+	}
+	<-s.done
+	cerr := s.p.Close()
+	s.mu.Lock()
+	err := s.err
+	s.mu.Unlock()
+	if err == nil {
+		err = cerr
+	}
+	if ferr := s.f.Close(); err == nil {
+		err = ferr
+	}
+	if s.created {
+		if rerr := os.Remove(s.name); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
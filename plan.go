@@ -0,0 +1,246 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Op is a file operation of rotation.
+type Op int
+
+// File operations.
+const (
+	OpRemove Op = iota
+	OpRename
+	OpCopy
+	OpCreate
+	OpTruncate
+	OpCompress
+)
+
+var ops = map[Op]string{
+	OpRemove:   "remove",
+	OpRename:   "rename",
+	OpCopy:     "copy",
+	OpCreate:   "create",
+	OpTruncate: "truncate",
+	OpCompress: "compress",
+}
+
+func (o Op) String() string {
+	if s, ok := ops[o]; ok {
+		return s
+	}
+	return fmt.Sprintf("Op(%d)", int(o))
+}
+
+// Step is a single operation of rotation. Name and To are relative to
+// a directory of a rotated file. To is set for OpRename, OpCopy and
+// OpCompress.
+type Step struct {
+	Op   Op
+	Name string
+	To   string
+}
+
+func (s Step) String() string {
+	if s.To == "" {
+		return fmt.Sprintf("%s %s", s.Op, s.Name)
+	}
+	return fmt.Sprintf("%s %s -> %s", s.Op, s.Name, s.To)
+}
+
+// planner is implemented by rotators which can plan rotation.
+type planner interface {
+	Plan() ([]Step, error)
+}
+
+// PlanRotation returns steps which the next rotation of f would take with
+// files as they are now, including removals by Config.Count, Config.MaxAge
+// and Config.TotalBytes. Nothing is changed on disk.
+// Sizes of compressed files are estimated by uncompressed ones.
+//
+// f must be returned by Wrap or Open. ErrNotSupported is returned if f
+// is not rotated on a current system.
+func PlanRotation(f File) ([]Step, error) {
+	ff, ok := unwrap(f)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	p, ok := ff.r.(planner)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return p.Plan()
+}
+
+// planned is a file tracked by a plan.
+type planned struct {
+	name  string
+	size  int64
+	mtime time.Time
+}
+
+func (r *rotator) Plan() ([]Step, error) {
+	var steps []Step
+	add := func(op Op, name, to string) {
+		steps = append(steps, Step{Op: op, Name: name, To: to})
+	}
+	// Files matching Config.KeepPatterns are forgotten, but not removed,
+	// see rotator.spare.
+	drop := func(name string) {
+		if !r.kept(name) {
+			add(OpRemove, name, "")
+		}
+	}
+	stat := func(s string) (planned, error) {
+		if s == "" {
+			return planned{}, nil
+		}
+		v, err := os.Stat(r.abs(s))
+		if os.IsNotExist(err) {
+			return planned{}, nil
+		}
+		if err != nil {
+			return planned{}, &Error{Filename: s, Err: err}
+		}
+		return planned{name: s, size: v.Size(), mtime: v.ModTime()}, nil
+	}
+
+	files := make([]planned, len(r.names))
+	for i, s := range r.names {
+		if i == 0 {
+			s = r.name
+		}
+		v, err := stat(s)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = v
+	}
+	files[0].name = r.name
+	legacy := make([]planned, len(r.legacy))
+	for i, s := range r.legacy {
+		v, err := stat(s)
+		if err != nil {
+			return nil, err
+		}
+		legacy[i] = v
+	}
+
+	// rotate
+	move := OpRename
+	if r.c.Method == CopyTruncate {
+		move = OpCopy
+	}
+	last := len(files) - 1
+	switch {
+	case r.c.Method == CopyTruncate && last == 0:
+		// A single file is only truncated.
+	case last == 0:
+		add(OpRemove, files[0].name, "")
+	case r.c.Naming == Timestamp:
+		if files[last].name != "" {
+			drop(files[last].name)
+		}
+		s := r.stampName(r.c.now())
+		add(move, files[0].name, s)
+		copy(files[2:], files[1:last])
+		files[1] = files[0]
+		files[1].name = s
+	default:
+		names := make([]string, len(files))
+		for i, v := range files {
+			names[i] = v.name
+		}
+		// A chain with a gap is compacted, see rotator.rename.
+		if !gapped(names) && files[last].name != "" {
+			drop(files[last].name)
+			files[last] = planned{}
+			names[last] = ""
+		}
+		g := firstEmpty(names)
+		names = shift(names, r.c.Naming)
+		for i := g - 1; i >= 0; i-- {
+			if files[i].name == "" {
+				continue
+			}
+			op := OpRename
+			if i == 0 {
+				op = move
+			}
+			add(op, files[i].name, names[i])
+			files[i].name = names[i]
+		}
+		copy(files[1:g+1], files[:g])
+	}
+	if r.c.Method == CopyTruncate {
+		add(OpTruncate, r.name, "")
+	} else {
+		add(OpCreate, r.name, "")
+	}
+	files[0] = planned{name: r.name}
+
+	// compress
+	if r.c.Compress && last > 0 && files[1].name != "" && !strings.HasSuffix(files[1].name, gzipExt) {
+		add(OpCompress, files[1].name, files[1].name+gzipExt)
+		files[1].name += gzipExt
+	}
+
+	// retain
+	n := int64(len(legacy)) + 1
+	for _, v := range files[1:] {
+		if v.name != "" {
+			n++
+		}
+	}
+	for ; n > r.c.Count && len(legacy) > 0; n-- {
+		drop(legacy[len(legacy)-1].name)
+		legacy = legacy[:len(legacy)-1]
+	}
+	if r.c.MaxAge > 0 {
+		t := r.c.now().Add(-r.c.MaxAge)
+		for len(legacy) > 0 && legacy[len(legacy)-1].mtime.Before(t) {
+			drop(legacy[len(legacy)-1].name)
+			legacy = legacy[:len(legacy)-1]
+		}
+		for i := last; i > 0; i-- {
+			if files[i].name == "" {
+				continue
+			}
+			if !files[i].mtime.Before(t) {
+				break
+			}
+			drop(files[i].name)
+			files[i] = planned{}
+		}
+	}
+	if r.c.TotalBytes > 0 {
+		var used int64
+		for _, v := range files[1:] {
+			used += v.size
+		}
+		for _, v := range legacy {
+			used += v.size
+		}
+		for len(legacy) > 0 && used > r.c.TotalBytes {
+			v := legacy[len(legacy)-1]
+			drop(v.name)
+			used -= v.size
+			legacy = legacy[:len(legacy)-1]
+		}
+		for i := last; i > 0 && used > r.c.TotalBytes; i-- {
+			if files[i].name == "" {
+				continue
+			}
+			drop(files[i].name)
+			used -= files[i].size
+			files[i] = planned{}
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,182 @@
+package rotate_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+var PlanRotationTests = []struct {
+	Names  []string
+	Config rotate.Config
+	Steps  []rotate.Step
+}{
+	{
+		[]string{"a"},
+		rotate.Config{Bytes: 1},
+		[]rotate.Step{
+			{Op: rotate.OpRemove, Name: "a"},
+			{Op: rotate.OpCreate, Name: "a"},
+		},
+	},
+	{
+		[]string{"a", "a.1", "a.2"},
+		rotate.Config{Bytes: 1, Count: 3},
+		[]rotate.Step{
+			{Op: rotate.OpRemove, Name: "a.2"},
+			{Op: rotate.OpRename, Name: "a.1", To: "a.2"},
+			{Op: rotate.OpRename, Name: "a", To: "a.1"},
+			{Op: rotate.OpCreate, Name: "a"},
+		},
+	},
+	{
+		[]string{"a", "a.1.gz"},
+		rotate.Config{Bytes: 1, Count: 3, Compress: true, Method: rotate.CopyTruncate},
+		[]rotate.Step{
+			{Op: rotate.OpRename, Name: "a.1.gz", To: "a.2.gz"},
+			{Op: rotate.OpCopy, Name: "a", To: "a.1"},
+			{Op: rotate.OpTruncate, Name: "a"},
+			{Op: rotate.OpCompress, Name: "a.1", To: "a.1.gz"},
+		},
+	},
+	{
+		[]string{"a", "a.1", "a.2.gz", "a.20181001T120000"},
+		rotate.Config{Bytes: 1, Count: 3, SkipScan: true},
+		[]rotate.Step{
+			{Op: rotate.OpRemove, Name: "a.2.gz"},
+			{Op: rotate.OpRename, Name: "a.1", To: "a.2"},
+			{Op: rotate.OpRename, Name: "a", To: "a.1"},
+			{Op: rotate.OpCreate, Name: "a"},
+		},
+	},
+}
+
+func TestPlanRotation(t *testing.T) {
+	for _, tt := range PlanRotationTests {
+		root := touch(t, tt.Names...)
+		defer os.RemoveAll(root)
+
+		f, err := os.OpenFile(root+"/a", rotate.OpenFlag, rotate.OpenPerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := rotate.Wrap(f, tt.Config)
+		if err == rotate.ErrNotSupported {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+
+		steps, err := rotate.PlanRotation(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(steps, tt.Steps) {
+			t.Errorf("%v: want %v, got %v", tt.Names, tt.Steps, steps)
+		}
+		for _, name := range tt.Names {
+			exist(t, root, name)
+		}
+	}
+}
+
+func TestRescan(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3})
+	defer r.Close()
+
+	touch2 := func(name string) {
+		f, err := open(root, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	touch2("a.1") // by external tools
+
+	if err := rotate.Rescan(r); err != nil {
+		t.Fatal(err)
+	}
+	steps, err := rotate.PlanRotation(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rotate.Step{
+		{Op: rotate.OpRename, Name: "a.1", To: "a.2"},
+		{Op: rotate.OpRename, Name: "a", To: "a.1"},
+		{Op: rotate.OpCreate, Name: "a"},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Fatalf("want %v, got %v", want, steps)
+	}
+}
+
+func TestPlanRotation_keepPatterns(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		names   []string
+		config  rotate.Config
+		removed []string
+	}{
+		{
+			"count",
+			[]string{"a", "a.20181001T120000"},
+			rotate.Config{Count: 2},
+			nil,
+		},
+		{
+			"maxage",
+			[]string{"a", "a.20181001T120000", "a.20181002T120000"},
+			rotate.Config{Count: 3, MaxAge: time.Hour},
+			[]string{"a.20181002T120000"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root := touch(t, tt.names...)
+			defer os.RemoveAll(root)
+			old := time.Date(2018, 10, 2, 12, 0, 0, 0, time.UTC)
+			for _, name := range tt.names[1:] {
+				if err := os.Chtimes(filepath.Join(root, name), old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c := tt.config
+			c.Naming = rotate.Timestamp
+			c.KeepPatterns = []string{"a.20181001*"}
+			r := ropen(t, root, "a", c)
+			defer r.Close()
+
+			steps, err := rotate.PlanRotation(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var removed []string
+			for _, s := range steps {
+				if s.Op == rotate.OpRemove {
+					removed = append(removed, s.Name)
+				}
+			}
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Fatalf("want %v removed, got %v", tt.removed, removed)
+			}
+
+			// Rotation removes the planned files only.
+			if v := <-rotate.ScheduleRotation(r, time.Now()); v.Err != nil {
+				t.Fatal(v.Err)
+			}
+			exist(t, root, "a.20181001T120000")
+			for _, name := range tt.removed {
+				notExist(t, root, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package rotate
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// Pool opens rotated files by name on demand and keeps them open until
+// Close or SetIdleTimeout. It is safe for concurrent use.
+type Pool struct {
+	c      Config
+	mu     sync.Mutex
+	files  map[string]*pooled
+	closed bool
+	idle   chan struct{} // closed to stop closeIdle, see SetIdleTimeout
+}
+
+// NewPool returns Pool opening files with c.
+func NewPool(c Config) *Pool {
+	return &Pool{c: c, files: make(map[string]*pooled)}
+}
+
+// Get returns a file name opened with Open. A file which is not rotated on
+// a current system is returned as well, like Open does.
+func (p *Pool) Get(name string) (File, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, os.ErrClosed
+	}
+	if f, ok := p.files[name]; ok {
+		return f, nil
+	}
+	f := &pooled{p: p, name: name}
+	if _, err := f.file(); err != nil {
+		return nil, err
+	}
+	p.files[name] = f
+	evictFiles()
+	return f, nil
+}
+
+// SetIdleTimeout makes p close files which are not written for d, so that
+// many sporadically written files do not exhaust file descriptors. A file
+// is reopened transparently on next use. If d == 0, files are kept open.
+func (p *Pool) SetIdleTimeout(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+	if d > 0 && !p.closed {
+		p.idle = make(chan struct{})
+		go p.closeIdle(d, p.idle)
+	}
+}
+
+// closeIdle closes files idle for d until done is closed.
+func (p *Pool) closeIdle(d time.Duration, done chan struct{}) {
+	t := time.NewTicker(d / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			p.mu.Lock()
+			for _, f := range p.files {
+				f.closeIdle(now().Add(-d))
+			}
+			p.mu.Unlock()
+		case <-done:
+			return
+		}
+	}
+}
+
+// Close closes all files of p and returns the first error.
+func (p *Pool) Close() (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+	for name, f := range p.files {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		delete(p.files, name)
+	}
+	return
+}
+
+// pooled is a file of Pool, which is reopened on use once closed as idle.
+type pooled struct {
+	p    *Pool
+	name string
+	mu   sync.Mutex
+	f    File // nil while closed
+	last time.Time
+	done bool          // closed by Close
+	elem *list.Element // in fds.lru while open, see SetPoolMaxOpen
+}
+
+// file returns an open file, which is reopened if needed. It must be
+// called under the lock unless f is not shared yet.
+func (f *pooled) file() (File, error) {
+	f.last = now()
+	if f.f != nil {
+		f.use()
+		return f.f, nil
+	}
+	if f.done {
+		return nil, os.ErrClosed
+	}
+	v, err := Open(f.name, f.p.c)
+	if err != nil && err != ErrNotSupported {
+		return nil, err
+	}
+	f.f = v
+	f.use()
+	return v, nil
+}
+
+// reopen returns an open file like file, but under the lock.
+func (f *pooled) reopen() (File, error) {
+	defer evictFiles() // after unlock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file()
+}
+
+// closeIdle closes a file not used since t. Errors are dropped, as there
+// is no caller to return them to.
+func (f *pooled) closeIdle(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f != nil && f.last.Before(t) {
+		_ = f.f.Close()
+		f.f = nil
+		f.forget()
+	}
+}
+
+func (f *pooled) Name() string { return f.name }
+
+func (f *pooled) Fd() uintptr {
+	defer evictFiles() // after unlock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, err := f.file(); err == nil {
+		return v.Fd()
+	}
+	return ^uintptr(0) // like Fd of a closed *os.File
+}
+
+func (f *pooled) Stat() (os.FileInfo, error) {
+	defer evictFiles() // after unlock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, err := f.file()
+	if err != nil {
+		return nil, err
+	}
+	return v.Stat()
+}
+
+func (f *pooled) Sync() error {
+	defer evictFiles() // after unlock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, err := f.file()
+	if err != nil {
+		return err
+	}
+	return v.Sync()
+}
+
+func (f *pooled) Write(b []byte) (int, error) {
+	defer evictFiles() // after unlock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, err := f.file()
+	if err != nil {
+		return 0, err
+	}
+	return v.Write(b)
+}
+
+func (f *pooled) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Flush flushes buffered writes, see Config.Shards.
+func (f *pooled) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.f.(flusher); ok {
+		return v.Flush()
+	}
+	return nil
+}
+
+func (f *pooled) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.done = true
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	f.forget()
+	return err
+}
@@ -0,0 +1,141 @@
+package rotate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Production returns a policy for long-running services: 100MB files,
+// 10 files in total, rotated at least daily and compressed.
+func Production() Config {
+	return Config{
+		Bytes:    100 * MB,
+		Count:    10,
+		Compress: true,
+		Interval: 24 * time.Hour,
+	}
+}
+
+// Development returns a policy for local runs: 10MB files, a single
+// rotated file and no compression, so logs are easy to read.
+func Development() Config {
+	return Config{
+		Bytes: 10 * MB,
+		Count: 2,
+	}
+}
+
+// String returns a policy in a form suitable for logs, e.g.
+//
+//     bytes=100MB count=10 interval=24h0m0s compress
+//
+// Fields with zero values and callbacks are omitted.
+func (c Config) String() string {
+	var v []string
+	add := func(format string, a ...interface{}) {
+		v = append(v, fmt.Sprintf(format, a...))
+	}
+	if c.Bytes > 0 {
+		add("bytes=%s", FormatBytes(c.Bytes))
+	}
+	if c.Count > 0 {
+		add("count=%d", c.Count)
+	}
+	if c.Interval > 0 {
+		add("interval=%s", c.Interval)
+	}
+	if c.MaxAge > 0 {
+		add("maxage=%s", c.MaxAge)
+	}
+	if c.TotalBytes > 0 {
+		add("totalbytes=%s quota=%s", FormatBytes(c.TotalBytes), c.Quota)
+	}
+	if c.Method != Rename {
+		add("method=%s", c.Method)
+	}
+	if c.Naming != Numeric {
+		add("naming=%s", c.Naming)
+	}
+	if c.Protect != ProtectNone {
+		add("protect=%s", c.Protect)
+	}
+	if c.Encoding != UTF8 {
+		add("encoding=%s", c.Encoding)
+	}
+	if c.SplitWrites != SplitNone {
+		add("split=%s", c.SplitWrites)
+	}
+	if c.MaxRotations > 0 {
+		period := c.RotationPeriod
+		if period <= 0 {
+			period = time.Minute
+		}
+		add("maxrotations=%d/%s", c.MaxRotations, period)
+	}
+	if len(c.Blackout) > 0 {
+		add("blackout=%d", len(c.Blackout))
+	}
+	if c.BytesPerSec > 0 {
+		add("bytespersec=%s", FormatBytes(c.BytesPerSec))
+	}
+	if c.Dedup > 0 {
+		add("dedup=%s", c.Dedup)
+	}
+	if c.Watch > 0 {
+		add("watch=%s", c.Watch)
+	}
+	if c.Shards > 0 {
+		add("shards=%d", c.Shards)
+	}
+	if c.Flag != 0 {
+		add("flag=%#x", c.Flag)
+	}
+	if c.Perm != 0 {
+		add("perm=%s", c.Perm)
+	}
+	if c.Stamp != "" {
+		add("stamp=%q", c.Stamp)
+	}
+	if c.MaxScan > 0 {
+		add("maxscan=%d", c.MaxScan)
+	}
+	if c.Priority != 0 {
+		add("priority=%d", c.Priority)
+	}
+	if c.SlowRotation > 0 {
+		add("slowrotation=%s", c.SlowRotation)
+	}
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"nolock", c.NoLock},
+		{"sync", c.SyncOnRotate},
+		{"syncrotated", c.SyncRotated},
+		{"rotationerrors", c.RotationErrors},
+		{"compress", c.Compress},
+		{"lumberjack", c.Lumberjack},
+		{"jsonlines", c.JSONLines},
+		{"multiline", c.Multiline},
+		{"promote", c.Promote},
+		{"utc", c.UseUTC},
+		{"anchored", c.Anchored},
+		{"cleanorphans", c.CleanOrphans},
+		{"linkduplicates", c.LinkDuplicates},
+		{"manifest", c.Manifest},
+		{"ids", c.IDs},
+		{"skipscan", c.SkipScan},
+		{"copyacl", c.CopyACL},
+		{"audit", c.Audit},
+		{"hash", c.Hash != nil},
+		{"sanitize", c.Sanitize.enabled()},
+		{"redact", c.Redact != nil},
+		{"sample", c.Sample != nil},
+	} {
+		if flag.set {
+			v = append(v, flag.name)
+		}
+	}
+	return strings.Join(v, " ")
+}
@@ -0,0 +1,33 @@
+package rotate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+var ConfigStringTests = []struct {
+	Config rotate.Config
+	S      string
+}{
+	{rotate.Config{}, ""},
+	{rotate.Production(), "bytes=100MB count=10 interval=24h0m0s compress"},
+	{rotate.Development(), "bytes=10MB count=2"},
+	{
+		rotate.Config{TotalBytes: rotate.GB, Quota: rotate.QuotaDrop, Method: rotate.CopyTruncate},
+		"totalbytes=1GB quota=drop method=copytruncate",
+	},
+	{rotate.Config{MaxRotations: 5}, "maxrotations=5/1m0s"},
+	{rotate.Config{Count: 2}.WithoutLock(), "count=2 nolock"},
+	{rotate.Config{MaxAge: time.Hour, Naming: rotate.Timestamp}, "maxage=1h0m0s naming=timestamp"},
+	{rotate.Config{Naming: rotate.Timestamp, UseUTC: true}, "naming=timestamp utc"},
+}
+
+func TestConfig_String(t *testing.T) {
+	for _, tt := range ConfigStringTests {
+		if s := tt.Config.String(); s != tt.S {
+			t.Errorf("want %q, got %q", tt.S, s)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// probeAge is an age of a probe of another process after which it is
+// considered left by a crashed run, see CleanOrphans. A probe lives for
+// a few system calls.
+const probeAge = time.Minute
+
+// probe checks that files can be created, renamed and removed in root, so
+// that a misconfigured directory is reported on Wrap instead of the first
+// rotation. A probe left by a crashed process of the same pid is removed.
+func probe(root, base string) error {
+	name := filepath.Join(root, "."+base+".probe"+strconv.Itoa(os.Getpid()))
+	renamed := name + ".1"
+	_ = remove(name)
+	_ = remove(renamed)
+	f, err := openFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, OpenPerm)
+	if err != nil {
+		return probeError(root, "create", err)
+	}
+	_ = f.Close()
+	if err = rename(name, renamed); err != nil {
+		_ = remove(name)
+		return probeError(root, "rename", err)
+	}
+	if err = remove(renamed); err != nil {
+		return probeError(root, "remove", err)
+	}
+	return nil
+}
+
+// isProbe reports whether s is a probe of base of any process.
+func isProbe(base, s string) bool {
+	s = strings.TrimSuffix(s, ".1")
+	prefix := "." + base + ".probe"
+	if !strings.HasPrefix(s, prefix) {
+		return false
+	}
+	_, err := strconv.Atoi(s[len(prefix):])
+	return err == nil
+}
+
+func probeError(root, op string, err error) error {
+	return &Error{Filename: root, Err: fmt.Errorf("can not %s files for rotation: %v", op, err)}
+}
@@ -0,0 +1,50 @@
+package rotate
+
+import (
+	"io"
+	"os"
+)
+
+// promote creates a file at a temporary name, initializes it with init and
+// renames it to name. The file is opened again at name, so that its Name is
+// right.
+func promote(name string, flag int, mode os.FileMode, gid int, init func(io.Writer) error) (*os.File, error) {
+	tmp := name + tmpExt
+	f, err := createFile(tmp, flag|os.O_TRUNC, mode, gid)
+	if err != nil {
+		return nil, err
+	}
+	err = init(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = rename(tmp, name)
+	}
+	if err != nil {
+		_ = remove(tmp)
+		return nil, err
+	}
+	return openFile(name, flag&^os.O_TRUNC, mode)
+}
+
+// initializer is implemented by rotators which can initialize a new file
+// before it appears at a canonical path, see Config.Promote.
+type initializer interface {
+	SetInit(init func(w io.Writer) error)
+}
+
+func (r *rotator) SetInit(init func(w io.Writer) error) { r.init = init }
+
+// prepare writes a header to a new file before it is promoted. The header
+// is counted by header once rotation completes.
+func (f *file) prepare(w io.Writer) error {
+	f.primed = nil
+	b, err := f.headerBytes()
+	if err != nil || len(b) == 0 {
+		return err
+	}
+	n, err := writeFull(w, b)
+	f.primed = b[:n]
+	return err
+}
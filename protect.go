@@ -0,0 +1,74 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+)
+
+// ProtectMode defines how rotated files are protected from tampering.
+type ProtectMode int
+
+// Protect modes.
+const (
+	// ProtectNone leaves rotated files writable.
+	ProtectNone ProtectMode = iota
+	// ProtectReadOnly removes write permissions of rotated files.
+	ProtectReadOnly
+	// ProtectImmutable sets immutable attribute of rotated files, like
+	// chattr +i does, so that even root can not change, rename or remove
+	// them without clearing it. It requires CAP_LINUX_IMMUTABLE and
+	// works on Linux only; elsewhere it works like ProtectReadOnly.
+	// A rotator clears the attribute itself to rename files by rotation
+	// and to remove them by retention policies.
+	ProtectImmutable
+)
+
+var protectModes = map[ProtectMode]string{
+	ProtectNone:      "none",
+	ProtectReadOnly:  "readonly",
+	ProtectImmutable: "immutable",
+}
+
+func (m ProtectMode) String() string {
+	if s, ok := protectModes[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("ProtectMode(%d)", int(m))
+}
+
+// protect protects the last rotated file according to Config.Protect.
+func (r *rotator) protect() error {
+	if r.c.Protect == ProtectNone || len(r.names) < 2 || r.names[1] == "" {
+		return nil
+	}
+	s := r.names[1]
+	err := os.Chmod(r.abs(s), r.mode.Perm()&^0222)
+	if err == nil && r.c.Protect == ProtectImmutable {
+		if err = setImmutable(r.abs(s), true); err == ErrNotSupported {
+			err = nil
+		}
+	}
+	if err != nil {
+		return &Error{Filename: s, Err: err}
+	}
+	return nil
+}
+
+// unprotect makes a rotated file s removable. If rename is set, it is
+// made renamable only and only an immutable attribute is cleared.
+func (r *rotator) unprotect(s string, rename bool) error {
+	var err error
+	if r.c.Protect == ProtectImmutable {
+		if err = setImmutable(r.abs(s), false); err == ErrNotSupported {
+			err = nil
+		}
+	}
+	if err == nil && r.c.Protect != ProtectNone && !rename {
+		// Read-only files can not be removed on Windows.
+		err = os.Chmod(r.abs(s), r.mode.Perm())
+	}
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+// +build linux
+
+package rotate
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fsImmutableFl is an inode flag of immutable files.
+const fsImmutableFl = 0x10
+
+// FS_IOC_GETFLAGS and FS_IOC_SETFLAGS ioctls are encoded with a size of
+// long, so they differ between 32 and 64 bit systems.
+const (
+	fsIocGetflags = 2<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 1
+	fsIocSetflags = 1<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 2
+)
+
+// setImmutable sets or clears immutable attribute of a file with name.
+func setImmutable(name string, on bool) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var flags int32
+	if err = ioctl(f.Fd(), fsIocGetflags, &flags); err != nil {
+		return err
+	}
+	v := flags &^ fsImmutableFl
+	if on {
+		v |= fsImmutableFl
+	}
+	if v == flags {
+		return nil
+	}
+	return ioctl(f.Fd(), fsIocSetflags, &v)
+}
+
+func ioctl(fd uintptr, req uintptr, flags *int32) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(flags)))
+	switch errno {
+	case 0:
+		return nil
+	case syscall.ENOTTY, syscall.ENOTSUP, syscall.EINVAL:
+		return ErrNotSupported
+	}
+	return errno
+}
@@ -0,0 +1,5 @@
+// +build !linux
+
+package rotate
+
+func setImmutable(name string, on bool) error { return ErrNotSupported }
@@ -0,0 +1,94 @@
+// Package publish announces rotated files to message queues, so that
+// consumers react to events rather than poll directories.
+//
+// Adapt a client of choice (Kafka, NATS, etc.) to Publisher:
+//
+//     type natsPublisher struct{ *nats.Conn }
+//
+//     func (p natsPublisher) Publish(topic string, msg []byte) error {
+//         return p.Conn.Publish(topic, msg)
+//     }
+//
+//     c := rotate.Config{
+//         OnRotate: publish.Hook(natsPublisher{nc}, "logs.sealed", publish.Options{}),
+//     }
+//
+package publish
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+// Publisher publishes a message to a topic.
+type Publisher interface {
+	Publish(topic string, msg []byte) error
+}
+
+// Options configures Hook.
+type Options struct {
+	// OnError is called when a message is not published.
+	OnError func(error)
+}
+
+// Message is published for each rotated file as JSON.
+type Message struct {
+	Path   string    `json:"path"`
+	Size   int64     `json:"size"`
+	SHA256 string    `json:"sha256"`
+	First  time.Time `json:"first"`
+	Last   time.Time `json:"last"`
+}
+
+// Hook returns a hook for rotate.Config.OnRotate which publishes Message to
+// topic. A checksum is calculated and a message is published in background,
+// so that writes are not blocked.
+func Hook(p Publisher, topic string, opt Options) func(rotate.RotationInfo) {
+	fail := func(err error) {
+		if opt.OnError != nil {
+			opt.OnError(err)
+		}
+	}
+	return func(info rotate.RotationInfo) {
+		if info.Filename == "" {
+			return // removed
+		}
+		// A file is opened at once, as it is renamed on next rotation.
+		f, err := os.Open(info.Filename)
+		if err != nil {
+			fail(err)
+			return
+		}
+		go func() {
+			defer f.Close()
+			msg, err := message(f, info)
+			if err == nil {
+				err = p.Publish(topic, msg)
+			}
+			if err != nil {
+				fail(err)
+			}
+		}()
+	}
+}
+
+func message(f *os.File, info rotate.RotationInfo) ([]byte, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Message{
+		Path:   info.Filename,
+		Size:   n,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+		First:  info.First,
+		Last:   info.Last,
+	})
+}
@@ -0,0 +1,50 @@
+package publish_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+	"github.com/koorgoo/rotate/publish"
+)
+
+type publisher chan []byte
+
+func (p publisher) Publish(topic string, msg []byte) error {
+	p <- msg
+	return nil
+}
+
+func TestHook(t *testing.T) {
+	root, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	name := filepath.Join(root, "a.1")
+	if err := ioutil.WriteFile(name, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := make(publisher, 1)
+	hook := publish.Hook(p, "sealed", publish.Options{OnError: func(err error) { t.Error(err) }})
+	hook(rotate.RotationInfo{Filename: name, Size: 3})
+
+	select {
+	case b := <-p:
+		var msg publish.Message
+		if err := json.Unmarshal(b, &msg); err != nil {
+			t.Fatal(err)
+		}
+		sum := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+		if msg.Path != name || msg.Size != 3 || msg.SHA256 != sum {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no message")
+	}
+}
@@ -0,0 +1,42 @@
+package rotate
+
+// passthrough reports whether no policy of c can trigger rotation or
+// change a write, so that writes may go straight to a current file.
+// Files can still be rotated by Rotor.Rotate, ScheduleRotation and
+// HandleSignals, so methods which need writes to stop during rotation,
+// CopyTruncate, Header and Promote, are excluded.
+func (c Config) passthrough() bool {
+	return c.Bytes == 0 && c.Interval == 0 && c.TotalBytes == 0 &&
+		c.Method != CopyTruncate && c.Header == nil && !c.Promote &&
+		c.Dedup == 0 && c.BytesPerSec == 0 && c.Watch == 0 && c.Shards == 0 &&
+		!c.JSONLines && !c.Multiline && c.Stamp == "" && c.Encoding != UTF16LE &&
+		c.Sample == nil && c.Redact == nil && !c.Sanitize.enabled() &&
+		c.OnWrite == nil && c.Hash == nil
+}
+
+// writeRaw writes b to a current file without the lock and bookkeeping,
+// see Config.passthrough. A write racing with rotation may fail on
+// a closed file, so it is retried on a new one unless a part of it is
+// written, so that a write is never split between files.
+func (f *file) writeRaw(b []byte) (n int, err error) {
+	for {
+		w := f.current()
+		n, err = writeFull(w.File, b)
+		if err == nil || n > 0 || f.current().seq == w.seq {
+			return
+		}
+	}
+}
+
+// size returns a size of a current file. Writes are not counted with
+// Config.passthrough, so a file is stat'ed then.
+func (f *file) size() int64 {
+	if !f.raw {
+		return f.n
+	}
+	v, err := f.w.Stat()
+	if err != nil {
+		return f.n
+	}
+	return v.Size()
+}
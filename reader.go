@@ -0,0 +1,134 @@
+package rotate
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Reader reads files of a rotation set as a single stream, from the oldest
+// rotated file to a current one. Compressed files are decompressed.
+//
+// All files are opened by NewReader, so that rotation during read does not
+// affect it.
+type Reader struct {
+	files []*os.File
+	cur   io.Reader // reader of files[0]
+}
+
+// NewReader returns Reader for a rotation set of a file with name.
+func NewReader(name string) (*Reader, error) {
+	return newReader(name, nil)
+}
+
+// newReader returns Reader for files of a rotation set for which keep
+// returns true.
+func newReader(name string, keep func(string, os.FileInfo) bool) (*Reader, error) {
+	files, err := openSet(name, keep)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{files: files}, nil
+}
+
+// openSet opens files of a rotation set for which keep returns true.
+// Files are sorted from the oldest to the newest.
+func openSet(name string, keep func(string, os.FileInfo) bool) ([]*os.File, error) {
+	root := filepath.Dir(name)
+	names, err := List(root, name)
+	if err != nil {
+		return nil, err
+	}
+	sortOldest(names)
+
+	var files []*os.File
+	for _, s := range names {
+		f, err := os.Open(filepath.Join(root, s))
+		if os.IsNotExist(err) {
+			continue // rotated meanwhile
+		}
+		if err != nil {
+			closeAll(files)
+			return nil, err
+		}
+		if keep != nil {
+			v, err := f.Stat()
+			if err != nil || !keep(s, v) {
+				_ = f.Close()
+				continue
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func closeAll(files []*os.File) (err error) {
+	for _, f := range files {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return
+}
+
+func (r *Reader) Read(p []byte) (n int, err error) {
+	for len(r.files) > 0 {
+		if r.cur == nil {
+			if r.cur, err = decompress(r.files[0]); err != nil {
+				return 0, err
+			}
+		}
+		n, err = r.cur.Read(p)
+		if err == io.EOF {
+			r.cur = nil
+			_ = r.files[0].Close()
+			r.files = r.files[1:]
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return
+	}
+	return 0, io.EOF
+}
+
+// Close closes files left to read.
+func (r *Reader) Close() error {
+	err := closeAll(r.files)
+	r.files = nil
+	return err
+}
+
+// CopySince copies data written since t to a rotation set of a file with
+// name into w. Files last written before t are skipped. Times of writes
+// are taken from a manifest (see Config.Manifest) or modification time of
+// files otherwise. As timestamps of single writes are unknown, a file
+// written since t is copied entirely.
+func CopySince(w io.Writer, name string, t time.Time) (int64, error) {
+	m, err := loadManifest(filepath.Join(filepath.Dir(name), manifestName(filepath.Base(name))))
+	if err != nil {
+		return 0, err
+	}
+	r, err := newReader(name, func(s string, v os.FileInfo) bool {
+		if e, ok := m.Files[genKey(s)]; ok && !e.Last.IsZero() {
+			return !e.Last.Before(t)
+		}
+		return !v.ModTime().Before(t)
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return io.Copy(w, r)
+}
+
+// sortOldest sorts names of a rotation set from the oldest to the newest.
+func sortOldest(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return newer(names[j], names[i])
+	})
+}
@@ -0,0 +1,95 @@
+package rotate_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+// fill writes content to files in root.
+func fill(t *testing.T, root string, content map[string]string) {
+	for name, s := range content {
+		err := ioutil.WriteFile(filepath.Join(root, name), []byte(s), rotate.OpenPerm)
+		if err != nil {
+			t.Fatalf("fill: %v", err)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	fill(t, root, map[string]string{"a": "3", "a.1": "2", "a.2": "1", "b": "x"})
+
+	r, err := rotate.NewReader(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "123" {
+		t.Fatalf("want %q, got %q", "123", b)
+	}
+}
+
+func TestCopySince(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	fill(t, root, map[string]string{"a": "3", "a.1": "2", "a.2": "1"})
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a.2"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	_, err := rotate.CopySince(&buf, filepath.Join(root, "a"), time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "23" {
+		t.Fatalf("want %q, got %q", "23", buf.String())
+	}
+}
+
+func TestCopySince_manifest(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 4, Manifest: true})
+	defer r.Close()
+	write(t, r, "1")
+	time.Sleep(10 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	write(t, r, "2") // rotation
+	write(t, r, "3") // rotation
+
+	// Modification time contradicts times of writes recorded in a manifest.
+	now, old := time.Now(), time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a.1"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(root, "a.2"), now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := rotate.CopySince(&buf, filepath.Join(root, "a"), since); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "23" {
+		t.Fatalf("want %q, got %q", "23", buf.String())
+	}
+}
@@ -0,0 +1,106 @@
+package rotate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// writeRecords writes complete records of b and holds an incomplete tail
+// until its newline is written. See Config.JSONLines.
+func (f *file) writeRecords(b []byte) (int, error) {
+	i := bytes.LastIndexByte(b, '\n')
+	if i < 0 {
+		f.partial = append(f.partial, b...)
+		return len(b), nil
+	}
+	msg := b[:i+1]
+	held := len(f.partial)
+	if held > 0 {
+		msg = append(f.partial, msg...)
+		f.partial = nil
+	}
+	n, err := f.message(msg)
+	if n < len(msg) {
+		if n -= held; n < 0 {
+			n = 0
+		}
+		return n, err
+	}
+	f.partial = append(f.partial, b[i+1:]...)
+	return len(b), err
+}
+
+// flushRecords writes a record held by writeRecords or writeGroups.
+func (f *file) flushRecords() error {
+	if len(f.partial) == 0 {
+		return nil
+	}
+	n, err := f.put(f.partial)
+	f.partial = nil
+	if n > 0 {
+		return nil
+	}
+	return err
+}
+
+// Records reads newline-delimited JSON records of a rotation set, from
+// the oldest rotated file to a current one. Compressed files are
+// decompressed. Empty lines are skipped.
+//
+// All files are opened by NewRecords, so that rotation during read does not
+// affect it.
+type Records struct {
+	files []*os.File
+	cur   *bufio.Reader // reader of files[0]
+}
+
+// NewRecords returns Records for a rotation set of a file with name.
+func NewRecords(name string) (*Records, error) {
+	files, err := openSet(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Records{files: files}, nil
+}
+
+// Next returns a next record or io.EOF if no records are left.
+// A record which is not valid JSON is returned with an error.
+func (r *Records) Next() (json.RawMessage, error) {
+	for len(r.files) > 0 {
+		if r.cur == nil {
+			z, err := decompress(r.files[0])
+			if err != nil {
+				return nil, err
+			}
+			r.cur = bufio.NewReader(z)
+		}
+		b, err := r.cur.ReadBytes('\n')
+		if err == io.EOF {
+			r.cur = nil
+			_ = r.files[0].Close()
+			r.files = r.files[1:]
+		} else if err != nil {
+			return nil, err
+		}
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+		if !json.Valid(b) {
+			return b, fmt.Errorf("rotate: invalid record %.32q", b)
+		}
+		return json.RawMessage(b), nil
+	}
+	return nil, io.EOF
+}
+
+// Close closes files left to read.
+func (r *Records) Close() error {
+	err := closeAll(r.files)
+	r.files = nil
+	return err
+}
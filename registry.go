@@ -0,0 +1,213 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+// registry holds files opened with Open by a canonical path, so that
+// a process has a single rotator per file.
+var registry = struct {
+	sync.Mutex
+	files map[string]*shared
+}{files: make(map[string]*shared)}
+
+// shared is a file opened with Open and a number of its handles.
+type shared struct {
+	f     File
+	c     Config
+	err   error // of open
+	refs  int
+	ready chan struct{} // closed once open returns
+}
+
+// handle is a File returned by Open. The file is closed when all handles
+// are closed.
+type handle struct {
+	File
+	key    string
+	mu     sync.RWMutex // held for reading by methods of File, see Close
+	closed bool
+}
+
+// openShared returns a handle of a file at name opened by open with c and
+// a canonical path, or of the file already opened by a previous call with
+// the same c. ErrConfigMismatch is returned if c differs.
+//
+// A file is opened without the registry lock, so that a hung open, e.g.
+// on an unresponsive network mount, blocks only opens of the same file.
+func openShared(name string, c Config, open func(string) (File, error)) (File, error) {
+	key, err := canonical(name)
+	if err != nil {
+		return nil, err
+	}
+	registry.Lock()
+	s, ok := registry.files[key]
+	if ok && !sameConfig(s.c, c) {
+		registry.Unlock()
+		return nil, ErrConfigMismatch
+	}
+	if !ok {
+		s = &shared{c: c, ready: make(chan struct{})}
+		registry.files[key] = s
+	}
+	s.refs++
+	registry.Unlock()
+
+	if ok {
+		<-s.ready
+	} else {
+		f, err := open(key)
+		registry.Lock()
+		s.f, s.err = f, err
+		if err != nil && err != ErrNotSupported {
+			delete(registry.files, key)
+		}
+		registry.Unlock()
+		close(s.ready)
+	}
+	if s.err != nil && s.err != ErrNotSupported {
+		return nil, s.err
+	}
+	return &handle{File: s.f, key: key}, s.err
+}
+
+// sameConfig reports whether a and b are equal. Functions and pointers
+// are equal if they are the same, as they can not be compared otherwise.
+func sameConfig(a, b Config) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		x, y := va.Field(i), vb.Field(i)
+		switch x.Kind() {
+		case reflect.Func, reflect.Ptr:
+			if x.Pointer() != y.Pointer() {
+				return false
+			}
+		default:
+			if !reflect.DeepEqual(x.Interface(), y.Interface()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (h *handle) Fd() uintptr {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return ^uintptr(0) // like Fd of a closed *os.File
+	}
+	return h.File.Fd()
+}
+
+func (h *handle) Stat() (os.FileInfo, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return nil, os.ErrClosed
+	}
+	return h.File.Stat()
+}
+
+func (h *handle) Sync() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	return h.File.Sync()
+}
+
+func (h *handle) Write(b []byte) (int, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	return h.File.Write(b)
+}
+
+func (h *handle) WriteString(s string) (int, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	return h.File.WriteString(s)
+}
+
+// Flush flushes buffered writes, see Config.Shards.
+func (h *handle) Flush() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	if v, ok := h.File.(flusher); ok {
+		return v.Flush()
+	}
+	return nil
+}
+
+// Close closes the file once it is the last open handle.
+func (h *handle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+
+	registry.Lock()
+	defer registry.Unlock()
+	s := registry.files[h.key]
+	if s.refs--; s.refs > 0 {
+		return nil
+	}
+	delete(registry.files, h.key)
+	return s.f.Close()
+}
+
+// canonical returns an absolute path of name with symlinks resolved, so
+// that rotated files are placed next to a real file. If name does not
+// exist, its directory is resolved.
+func canonical(name string) (string, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+	if s, err := filepath.EvalSymlinks(abs); err == nil {
+		return s, nil
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return abs, nil // fails on open
+	}
+	return filepath.Join(dir, filepath.Base(abs)), nil
+}
+
+// unwrap returns *file behind f returned by Wrap, Open or Pool.Get.
+// A pooled file is reopened if it is closed as idle or evicted.
+func unwrap(f File) (*file, bool) {
+	if v, ok := f.(*pooled); ok {
+		w, err := v.reopen()
+		if err != nil {
+			return nil, false
+		}
+		f = w
+	}
+	if v, ok := f.(*Rotor); ok {
+		f = v.f
+	}
+	if v, ok := f.(*handle); ok {
+		f = v.File
+	}
+	if v, ok := f.(*sharded); ok {
+		f = v.f
+	}
+	v, ok := f.(*file)
+	return v, ok
+}
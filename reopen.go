@@ -0,0 +1,40 @@
+package rotate
+
+import (
+	"errors"
+	"time"
+)
+
+// Backoff of retries to create a file after rotation.
+const (
+	minReopenBackoff = time.Second
+	maxReopenBackoff = time.Minute
+)
+
+// errPending is returned by rotator while a reopen is not retried yet.
+var errPending = errors.New("rotate: reopen pending")
+
+// retryReopen retries to create a current file once backoff has passed.
+func (r *rotator) retryReopen() error {
+	if now().Before(r.retryAt) {
+		return errPending
+	}
+	if err := r.reopen(); err != nil {
+		return r.failReopen(err)
+	}
+	r.pending = false
+	return nil
+}
+
+// failReopen schedules a next reopen and wraps err.
+func (r *rotator) failReopen(err error) error {
+	r.backoff *= 2
+	if r.backoff < minReopenBackoff {
+		r.backoff = minReopenBackoff
+	}
+	if r.backoff > maxReopenBackoff {
+		r.backoff = maxReopenBackoff
+	}
+	r.retryAt = now().Add(r.backoff)
+	return &Error{Filename: r.name, Err: err}
+}
@@ -0,0 +1,111 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// QuarantineDir is a directory in a directory of a rotated file, where
+// Repair moves corrupt compressed files.
+const QuarantineDir = ".quarantine"
+
+// Repair fixes problems of rotated files of base in root found by Verify,
+// e.g. after manual intervention. Corrupt compressed files are moved to
+// QuarantineDir, counters are renumbered to close gaps preserving the
+// order and a manifest (see Config.Manifest), if any, is updated: its
+// generation is raised to a number of rotated files if less and entries
+// of files renamed by Repair follow them. Other entries, e.g. of files
+// removed or moved manually, are left as is. Duplicates and checksum
+// mismatches are left to an operator.
+//
+// Steps taken are returned. Files must not be rotated meanwhile.
+func Repair(root, base string) ([]Step, error) {
+	base = filepath.Base(base)
+	names, err := List(root, base)
+	if err != nil {
+		return nil, err
+	}
+	var steps []Step
+	move := func(from, to string) error {
+		if _, err := os.Lstat(filepath.Join(root, to)); err == nil {
+			return &Error{Filename: to, Err: os.ErrExist}
+		}
+		if err := rename(filepath.Join(root, from), filepath.Join(root, to)); err != nil {
+			return err
+		}
+		steps = append(steps, Step{Op: OpRename, Name: from, To: to})
+		return nil
+	}
+
+	var rotated []string
+	for _, s := range names {
+		if s == base {
+			continue
+		}
+		if strings.HasSuffix(s, gzipExt) && verifyGzip(filepath.Join(root, s)) != nil {
+			if err := os.MkdirAll(filepath.Join(root, QuarantineDir), 0755); err != nil {
+				return steps, err
+			}
+			if err := move(s, filepath.Join(QuarantineDir, s)); err != nil {
+				return steps, err
+			}
+			continue
+		}
+		rotated = append(rotated, s)
+	}
+
+	// Files are renamed from the newest, so that a target is free: the k-th
+	// distinct counter becomes k. Duplicates keep a common counter.
+	var k, last int64
+	for _, s := range rotated {
+		b, n, t, ext := parse(s)
+		if !t.IsZero() {
+			continue
+		}
+		if n != last {
+			k++
+			last = n
+		}
+		if n == k {
+			continue
+		}
+		scheme := Numeric
+		if s == ZeroPadded.format(b, n, time.Time{}, ext) && s != Numeric.format(b, n, time.Time{}, ext) {
+			scheme = ZeroPadded
+		}
+		if err := move(s, scheme.format(b, k, time.Time{}, ext)); err != nil {
+			return steps, err
+		}
+	}
+
+	mname := manifestName(base)
+	if _, err := os.Stat(filepath.Join(root, mname)); err == nil {
+		m, err := loadManifest(filepath.Join(root, mname))
+		if err != nil {
+			m = new(manifest)
+		}
+		moved := false
+		for _, v := range steps {
+			if _, ok := m.Files[genKey(v.Name)]; ok {
+				to := v.To
+				if strings.HasPrefix(to, QuarantineDir+string(filepath.Separator)) {
+					to = ""
+				}
+				m.move(v.Name, to)
+				moved = true
+			}
+		}
+		if n := int64(len(rotated)); err != nil || moved || m.Generation < n {
+			if m.Generation < n {
+				m.Generation = n
+			}
+			if err := m.save(filepath.Join(root, mname), 0644); err != nil {
+				return steps, err
+			}
+			steps = append(steps, Step{Op: OpCreate, Name: mname})
+		}
+	}
+	return steps, nil
+}
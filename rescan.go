@@ -0,0 +1,48 @@
+package rotate
+
+// rescanner is implemented by rotators which cache a rotation set.
+type rescanner interface {
+	Rescan() error
+}
+
+// Rescan re-reads a rotation set of f from disk. A rotation set is read
+// once on Wrap and then maintained by rotation, so Rescan is needed only
+// when external tools add, remove or rename rotated files.
+//
+// f must be returned by Wrap or Open. ErrNotSupported is returned if f
+// is not rotated on a current system.
+func Rescan(f File) error {
+	ff, ok := unwrap(f)
+	if !ok {
+		return ErrNotSupported
+	}
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	r, ok := ff.r.(rescanner)
+	if !ok {
+		return ErrNotSupported
+	}
+	return r.Rescan()
+}
+
+func (r *rotator) Rescan() (err error) {
+	if err = r.compressed(); err != nil {
+		return err
+	}
+	if len(r.names) > 1 {
+		names, err := listNames(r.root, r.name, r.c)
+		if err != nil {
+			return err
+		}
+		r.names = names
+	}
+	if r.c.Lumberjack {
+		if r.legacy, err = listLumberjack(r.root, r.name); err != nil {
+			return err
+		}
+	}
+	if r.c.TotalBytes > 0 {
+		r.usage()
+	}
+	return nil
+}
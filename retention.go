@@ -0,0 +1,269 @@
+package rotate
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// pruner is implemented by rotators which can remove rotated files.
+type pruner interface {
+	// Rotated returns a total size of rotated files.
+	Rotated() int64
+	// Prune removes the oldest rotated files until their size fits max.
+	Prune(max int64) error
+}
+
+// Retention policies, see AuditRecord.
+const (
+	byCount      = "count"
+	byMaxAge     = "maxage"
+	byTotalBytes = "totalbytes"
+	byBudget     = "budget"
+)
+
+// fits reports whether n bytes can be written within Config.TotalBytes.
+// Rotated files are pruned to make room if needed.
+func (f *file) fits(n int) bool {
+	if f.c.TotalBytes <= 0 {
+		return true
+	}
+	free := f.c.TotalBytes - f.n - int64(n)
+	p, ok := f.r.(pruner)
+	if !ok {
+		return free >= 0
+	}
+	if p.Rotated() <= free {
+		return true
+	}
+	// Rotation errors are not returned from a write, if it succeeds.
+	_ = p.Prune(free)
+	return p.Rotated() <= free
+}
+
+func (r *rotator) Rotated() int64 { return r.used }
+
+func (r *rotator) Prune(max int64) error { return r.prune(max, byTotalBytes) }
+
+// prune is Prune recording policy in an audit file.
+func (r *rotator) prune(max int64, policy string) error {
+	var victims []string
+	used := r.used
+	for i := len(r.legacy) - 1; i >= 0 && used > max; i-- {
+		victims = append(victims, r.legacy[i])
+		used -= r.size(r.legacy[i])
+	}
+	for i := len(r.names) - 1; i > 0 && used > max; i-- {
+		if s := r.names[i]; s != "" {
+			victims = append(victims, s)
+			used -= r.size(s)
+		}
+	}
+	return r.removeAll(victims, policy)
+}
+
+func (r *rotator) size(s string) int64 {
+	if v, err := os.Stat(r.abs(s)); err == nil {
+		return v.Size()
+	}
+	return 0
+}
+
+// removeWorkers is a maximum number of concurrent removals.
+const removeWorkers = 8
+
+// removeAll removes rotated files victims concurrently by policy and
+// forgets them. Their size is subtracted from usage. Errors are aggregated.
+// Files matching Config.KeepPatterns are forgotten, but not removed.
+func (r *rotator) removeAll(victims []string, policy string) error {
+	// A file being compressed is removed once compressed.
+	for i, s := range victims {
+		if r.zip != nil && s == r.zip.name && r.compressed() == nil {
+			victims[i] = s + gzipExt
+		}
+	}
+	victims, kept := r.spare(victims)
+	if len(victims) == 0 && len(kept) == 0 {
+		return nil
+	}
+	removed := make(map[string]bool, len(victims)+len(kept))
+	for _, s := range kept {
+		removed[s] = true
+		r.used -= r.size(s)
+	}
+	type result struct {
+		name string
+		size int64
+		err  error
+	}
+	ch := make(chan string)
+	results := make(chan result)
+	workers := removeWorkers
+	if len(victims) < workers {
+		workers = len(victims)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for s := range ch {
+				size, err := r.removeRotated(s)
+				results <- result{s, size, err}
+			}
+		}()
+	}
+	go func() {
+		for _, s := range victims {
+			ch <- s
+		}
+		close(ch)
+	}()
+
+	var errs errorList
+	for range victims {
+		v := <-results
+		if v.err != nil {
+			errs = append(errs, v.err)
+			continue
+		}
+		removed[v.name] = true
+		r.used -= v.size
+		r.moveGeneration(v.name, "")
+	}
+	var names []string // in order of victims
+	for _, s := range victims {
+		if removed[s] {
+			names = append(names, s)
+		}
+	}
+	if err := r.audit("remove", policy, names...); err != nil {
+		errs = append(errs, err)
+	}
+	legacy := r.legacy[:0]
+	for _, s := range r.legacy {
+		if !removed[s] {
+			legacy = append(legacy, s)
+		}
+	}
+	r.legacy = legacy
+	for i, s := range r.names {
+		if i > 0 && removed[s] {
+			r.names[i] = ""
+		}
+	}
+	return errs.err()
+}
+
+// removeRotated removes a rotated file and returns its size.
+func (r *rotator) removeRotated(s string) (int64, error) {
+	v, err := os.Stat(r.abs(s))
+	if err == nil {
+		err = r.unprotect(s, false)
+	}
+	if err == nil {
+		err = remove(r.abs(s))
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return 0, &Error{Filename: s, Err: err}
+	}
+	if v != nil {
+		return v.Size(), nil
+	}
+	return 0, nil
+}
+
+// errorList aggregates errors of concurrent operations.
+type errorList []error
+
+func (e errorList) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// err returns nil, a single error or e.
+func (e errorList) err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	}
+	return e
+}
+
+// usage re-calculates a size of rotated files.
+func (r *rotator) usage() {
+	r.used = 0
+	for _, names := range [][]string{r.names[1:], r.legacy} {
+		for _, s := range names {
+			if s != "" {
+				r.used += r.size(s)
+			}
+		}
+	}
+}
+
+// retain removes rotated files according to Config.Count, Config.MaxAge
+// and Config.TotalBytes.
+func (r *rotator) retain() error {
+	if err := r.trimLegacy(); err != nil {
+		return err
+	}
+	if r.c.MaxAge > 0 {
+		if err := r.expire(r.c.now().Add(-r.c.MaxAge)); err != nil {
+			return err
+		}
+	}
+	if r.c.TotalBytes > 0 {
+		r.usage()
+		return r.prune(r.c.TotalBytes, byTotalBytes)
+	}
+	return nil
+}
+
+// trimLegacy removes legacy files exceeding Config.Count together with
+// files of a rotation chain.
+func (r *rotator) trimLegacy() error {
+	n := int64(len(r.legacy)) + 1 // + a current file
+	for _, s := range r.names[1:] {
+		if s != "" {
+			n++
+		}
+	}
+	var victims []string
+	for i := len(r.legacy) - 1; n > r.c.Count && i >= 0; i-- {
+		victims = append(victims, r.legacy[i])
+		n--
+	}
+	return r.removeAll(victims, byCount)
+}
+
+// expire removes rotated files modified before t.
+func (r *rotator) expire(t time.Time) error {
+	var victims []string
+	for i := len(r.legacy) - 1; i >= 0; i-- {
+		if !r.expired(r.legacy[i], t) {
+			break // newer files follow
+		}
+		victims = append(victims, r.legacy[i])
+	}
+	if len(victims) == len(r.legacy) {
+		for i := len(r.names) - 1; i > 0; i-- {
+			s := r.names[i]
+			if s == "" {
+				continue
+			}
+			if !r.expired(s, t) {
+				break // newer files follow
+			}
+			victims = append(victims, s)
+		}
+	}
+	return r.removeAll(victims, byMaxAge)
+}
+
+func (r *rotator) expired(s string, t time.Time) bool {
+	v, err := os.Stat(r.abs(s))
+	return err != nil || v.ModTime().Before(t)
+}
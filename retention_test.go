@@ -0,0 +1,211 @@
+// +build linux
+
+package rotate_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_totalBytesRemovesOldest(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 2, Count: 5, TotalBytes: 5})
+	defer r.Close()
+
+	for i := 0; i < 4; i++ {
+		write(t, r, "12")
+	}
+
+	// a (2) + a.1 (2) fit; a.2 is removed.
+	exist(t, root, "a.1")
+	notExist(t, root, "a.2")
+	notExist(t, root, "a.3")
+}
+
+func TestFile_quotaError(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{TotalBytes: 3, Quota: rotate.QuotaError})
+	defer r.Close()
+
+	write(t, r, "12")
+	if _, err := r.WriteString("34"); err != rotate.ErrQuotaExceeded {
+		t.Fatalf("want ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestFile_quotaDrop(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{TotalBytes: 3, Quota: rotate.QuotaDrop})
+	defer r.Close()
+
+	write(t, r, "12")
+	if n := write(t, r, "34"); n != 2 {
+		t.Fatalf("want 2 bytes, wrote %d bytes", n)
+	}
+
+	v, err := r.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Size() != 2 {
+		t.Fatalf("want 2 bytes on disk, got %d", v.Size())
+	}
+}
+
+func TestFile_totalBytesRemovesMany(t *testing.T) {
+	names := []string{"a"}
+	for i := 1; i < 100; i++ {
+		names = append(names, fmt.Sprintf("a.%d", i))
+	}
+	root := touch(t, names...)
+	defer os.RemoveAll(root)
+
+	for _, name := range names[1:] {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte("1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a config change leaves room for a single rotated file
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 100, TotalBytes: 2})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "1") // rotation
+
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 2 {
+		t.Fatalf("want a and a.1, got %v", v)
+	}
+}
+
+func TestFile_audit(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 2, Count: 3, Compress: true, Audit: true})
+	defer r.Close()
+
+	for i := 0; i < 4; i++ {
+		write(t, r, "12")
+	}
+	if err := r.Sync(); err != nil { // waits for compression
+		t.Fatal(err)
+	}
+
+	records, err := rotate.ReadAudit(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range records {
+		if v.Time.IsZero() {
+			t.Errorf("%v: no time", v)
+		}
+		got = append(got, fmt.Sprintf("%s %s %s", v.Action, v.File, v.Policy))
+	}
+	want := []string{
+		"compress a.1 compress",
+		"compress a.1 compress",
+		"remove a.2.gz count",
+		"compress a.1 compress",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestFile_auditFailure(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+	// An audit file can not be opened.
+	if err := os.Mkdir(filepath.Join(root, ".a.audit"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var failed int
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 2, Audit: true, OnEvent: func(e rotate.Event) {
+		if e.Type == rotate.RotationFailed {
+			failed++
+		}
+	}})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "2") // rotation
+	write(t, r, "3") // rotation removing a.1
+
+	if failed != 1 {
+		t.Fatalf("want 1 failed rotation, got %d", failed)
+	}
+	for name, want := range map[string]string{"a": "3", "a.1": "2"} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+}
+
+func TestFile_keepPatterns(t *testing.T) {
+	root := touch(t, "a", "a.20181001T120000")
+	defer os.RemoveAll(root)
+
+	var events []rotate.Event
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes:        1,
+		Count:        2,
+		Naming:       rotate.Timestamp,
+		KeepPatterns: []string{"a.2018*"},
+		OnEvent:      func(e rotate.Event) { events = append(events, e) },
+	})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "1") // rotation
+
+	exist(t, root, "a.20181001T120000")
+	if len(events) > 0 {
+		t.Fatalf("want no failed rotation, got events %v", events)
+	}
+}
+
+func TestFile_keepPatternsInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		naming rotate.NamingScheme
+		keep   string
+		err    error
+	}{
+		{"pattern", rotate.Timestamp, "[", filepath.ErrBadPattern},
+		{"numeric", rotate.Numeric, "a.1", rotate.ErrKeepNaming},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root := touch(t)
+			defer os.RemoveAll(root)
+
+			c := rotate.Config{Naming: tt.naming, KeepPatterns: []string{tt.keep}}
+			_, err := rotate.Open(filepath.Join(root, "a"), c)
+			if err != tt.err {
+				t.Fatalf("want %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
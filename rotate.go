@@ -1,7 +1,9 @@
 package rotate
 
 import (
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
@@ -11,15 +13,44 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 // ErrNotSupported is returned when rotation is not supported on a current system.
 var ErrNotSupported = fmt.Errorf("rotate: not supported on %s", runtime.GOOS)
 
-// OpenFlag is used to open a file after rotation.
+// now is a clock used by time-based policies.
+var now = time.Now
+
+// ErrQuotaExceeded is returned by write exceeding Config.TotalBytes.
+// See QuotaError.
+var ErrQuotaExceeded = errors.New("rotate: quota exceeded")
+
+// ErrShared is returned by Rotor.Detach of a file opened by Open more than
+// once.
+var ErrShared = errors.New("rotate: file is shared")
+
+// ErrConfigMismatch is returned by Open of a file already opened by
+// a process with another Config. Functions and pointers of Config are
+// compared by identity.
+var ErrConfigMismatch = errors.New("rotate: file is open with another Config")
+
+// ErrRenameTimeout is returned when renames of rotated files exceed
+// Config.RenameTimeout.
+var ErrRenameTimeout = errors.New("rotate: rename timeout")
+
+// OpenFlag is used to open a file after rotation unless Config.Flag is set.
 const OpenFlag int = os.O_APPEND | os.O_CREATE | os.O_WRONLY
 
-// Error is returned when rotation fails. It does not cancel write.
+// Error describes a failed rotation. It is emitted with RotationFailed event
+// and never cancels a write. Errors of writes themselves are returned from
+// Write as is, so they are never *Error.
+//
+// n returned by Write with *Error is len(b), as a write succeeded to
+// a current file. n returned with any other error is a number of bytes
+// written before the error.
 type Error struct {
 	Filename string
 	Err      error
@@ -39,8 +70,340 @@ type Config struct {
 	// If Count <= 1, a file will be removed & created on Bytes size.
 	Count int64
 	// Lock defines whether to lock on write.
-	// Must be set for asynchronous writes.
+	//
+	// Deprecated: Writes are locked unless NoLock is set.
 	Lock bool
+	// Blackout defines daily windows in which size-triggered rotation is
+	// deferred. Writes continue to the current file until a window ends.
+	Blackout []Window
+	// MaxRotations caps the number of rotations per RotationPeriod.
+	// Beyond the cap, writes continue to the current file past Bytes and
+	// RotationLimited event is emitted.
+	// If MaxRotations == 0, rotations are not limited.
+	MaxRotations int
+	// RotationPeriod is a period for MaxRotations. Defaults to a minute.
+	RotationPeriod time.Duration
+	// OnEvent is called on notable situations which are not errors.
+	// It is called synchronously and must not write to the file.
+	OnEvent func(Event)
+	// OnWrite is called after each write with n bytes written and a total
+	// amount of bytes written since Wrap.
+	// It is called outside of the lock, so calls may interleave.
+	OnWrite func(n int, total int64)
+	// Dedup collapses runs of identical writes into a single
+	// "last message repeated N times" line. A run ends on a different write
+	// or when Dedup has passed since the start of a run.
+	// If Dedup == 0, writes are not deduplicated.
+	Dedup time.Duration
+	// DedupEqual compares writes for Dedup. Defaults to bytes.Equal.
+	DedupEqual func(a, b []byte) bool
+	// BytesPerSec caps write throughput. Writes exceeding the cap are
+	// dropped and reported as successful; a number of dropped writes is
+	// reported with WritesDropped event once writes pass again. A write
+	// larger than BytesPerSec passes after a second without writes and
+	// its excess is taken from the following seconds.
+	// If BytesPerSec == 0, throughput is not limited.
+	BytesPerSec int64
+	// Watch sets an interval to check whether the current file was removed,
+	// renamed or truncated by external tools. If so, the file is reopened
+	// or the size counter is re-synced.
+	// Watch overrides NoLock. If Watch == 0, no checks happen.
+	Watch time.Duration
+	// Method defines how a current file is rotated. Defaults to Rename.
+	Method Method
+	// SyncOnRotate syncs a current file before it is rotated, so that the
+	// rotated file is durable before older ones are removed.
+	// Rotation does not happen if sync fails.
+	SyncOnRotate bool
+	// TotalBytes caps a total size of current and rotated files.
+	// The oldest rotated files are removed to fit the cap.
+	// If TotalBytes == 0, total size is not limited.
+	TotalBytes int64
+	// Quota defines what happens to a write when TotalBytes is reached and
+	// no rotated files are left to remove. Defaults to QuotaIgnore.
+	Quota Quota
+	// OnRotate is called after each rotation.
+	// It is called outside of the lock, so calls may interleave.
+	OnRotate func(RotationInfo)
+	// Compress compresses rotated files with gzip, adding ".gz" extension.
+	// Compression happens in the background after rotation, so that writes
+	// are not blocked. Flush, Sync, Close, next rotation and OnRotate wait
+	// for it.
+	Compress bool
+	// MaxAge is the maximum age of rotated files by modification time.
+	// Older files are removed on rotation.
+	// If MaxAge == 0, files are not removed by age.
+	MaxAge time.Duration
+	// Lumberjack adopts backups of gopkg.in/natefinch/lumberjack, e.g.
+	// app-2018-10-01T15-04-05.000.log for app.log. They are counted as
+	// the oldest rotated files by Count, MaxAge and TotalBytes.
+	Lumberjack bool
+	// Naming defines names of rotated files. Defaults to Numeric.
+	Naming NamingScheme
+	// Interval rotates a non-empty current file on the first write after
+	// an interval boundary. Boundaries are aligned to local midnight, so
+	// 24 * time.Hour rotates daily and 6 * time.Hour at 00:00, 06:00, etc.
+	// If Interval == 0, no time-based rotation happens. See Anchored.
+	Interval time.Duration
+	// SyncRotated makes Sync and Close also sync files rotated since
+	// the last Sync and their directory, so that data written before
+	// a successful Sync is durable, wherever rotation moved it.
+	SyncRotated bool
+	// RotationErrors makes Write return *Error of a failed rotation if
+	// the write itself succeeds, like earlier versions did. n is the number
+	// of bytes written either way.
+	RotationErrors bool
+	// SplitWrites defines how a write larger than Bytes is split across
+	// rotations. Defaults to SplitNone.
+	SplitWrites SplitMode
+	// NoLock disables locking on write. It saves a little time when
+	// the file is written by a single goroutine, but concurrent writes
+	// race. See WithoutLock.
+	NoLock bool
+	// Shards buffers writes in Shards buffers picked in turn, so that
+	// concurrent writers rarely wait for each other's writes to the file.
+	// Buffers are not per CPU: every write increments a sequence number
+	// shared by all writers. Buffers are flushed to the file by a single
+	// goroutine every 100ms, once a buffer holds 64KB, and on Flush, Sync
+	// and Close. Writes of a goroutine keep their order.
+	// Errors of writes are emitted with FlushFailed event.
+	// If Shards == 0, writes are not buffered.
+	Shards int
+	// JSONLines treats writes as newline-delimited JSON records. An
+	// incomplete record is held until its newline is written, so that
+	// rotation happens only between records and SplitWrites splits writes
+	// at record ends. A held record is written on Close.
+	// See Records to read records of a rotation set.
+	JSONLines bool
+	// Header is called to write a header to the top of every new file:
+	// an empty file on Wrap and a file created or truncated by rotation.
+	// A header counts towards Bytes. If a header fails on Wrap, Wrap closes
+	// f. See CSVHeader.
+	Header func(w io.Writer) error
+	// SizeFunc returns a size of a write to count towards Bytes, e.g.
+	// a size of data before compression by a caller. Bytes on disk are
+	// still used for TotalBytes. A size of a file on Wrap is counted as is.
+	// SplitWrites is not applied with SizeFunc.
+	// If SizeFunc == nil, bytes written are counted.
+	SizeFunc func(b []byte) int64
+	// Manifest keeps metadata of rotated files in a hidden file
+	// .<name>.manifest next to a file, so that RotationInfo.Generation
+	// persists across restarts. Generations and times of writes of rotated
+	// files are used by OpenRotation and CopySince.
+	Manifest bool
+	// IDs stamps every new current file with a random UUID in user.rotate.id
+	// extended attribute, which follows the file through renames and
+	// compression, so that shippers can track it. See ID and
+	// RotationInfo.ID. Files are not stamped on systems and filesystems
+	// without extended attributes.
+	IDs bool
+	// MaxScan caps a number of directory entries read on Wrap to find
+	// rotated files. Rotated files which are not found are never removed,
+	// but may be overwritten by rotation.
+	// If MaxScan == 0, a whole directory is read.
+	MaxScan int
+	// SkipScan finds rotated files by their names in a chain instead of
+	// reading a directory, asserting the directory holds no other rotated
+	// files of the file, e.g. ones named by another naming scheme.
+	// It is ignored with Timestamp naming.
+	SkipScan bool
+	// Flag is used to open a file in Open and after rotation, e.g.
+	// OpenFlag | os.O_SYNC. If Flag == 0, OpenFlag is used. os.O_CREATE
+	// is always added after rotation, as a file does not exist then.
+	Flag int
+	// Perm is used to create a file in Open. After rotation, a file is
+	// created with mode of a rotated one. If Perm == 0, OpenPerm is used.
+	Perm os.FileMode
+	// Group is a name or an ID of a group of created files, e.g. "adm",
+	// so that they can be read by its members. It is ignored on Windows.
+	// If Group == "", files get a default group.
+	Group string
+	// CopyACL copies a POSIX access ACL of a current file to a new one
+	// after rotation, so that read grants survive rotation. It works on
+	// Linux only. An error of the copy does not cancel rotation.
+	CopyACL bool
+	// Priority orders pruning by Manager: rotated files of files with
+	// a lower priority are pruned first, e.g. debug logs before audit
+	// ones. Files of equal priority share an excess of a budget.
+	Priority int
+	// Audit appends a JSON record of every rotated file removed or
+	// compressed to a hidden file .<name>.audit next to a file, so that
+	// it can be proven that files were removed by policy. See ReadAudit.
+	Audit bool
+	// Protect protects rotated files from tampering. Files are protected
+	// after compression. Defaults to ProtectNone.
+	Protect ProtectMode
+	// KeepPatterns are glob patterns of rotated files, e.g. "*.20181001T*"
+	// for files of a day of an incident, which are never removed by Count,
+	// MaxAge, TotalBytes or Manager. Such files are left out of a rotation
+	// set instead. Names of a numeric chain shift on every rotation, so
+	// KeepPatterns require Timestamp naming; see ErrKeepNaming.
+	KeepPatterns []string
+	// Hash returns a hash of content written to a current file, e.g.
+	// sha256.New, so that a file can be verified without re-reading it.
+	// A sum is reported with RotationInfo.Hash.
+	// If Hash == nil, content is not hashed.
+	Hash func() hash.Hash
+	// Stamp prefixes each line with time of its write formatted with
+	// Stamp layout and a space, e.g. time.RFC3339, for writers which
+	// do not timestamp lines themselves. See StampUnix.
+	// If Stamp == "", lines are written as is.
+	Stamp string
+	// Sanitize cleans up writes, e.g. of subprocess output. On a partial
+	// write, n is a number of sanitized bytes written up to a size of
+	// the write.
+	Sanitize Sanitize
+	// Redact returns a write with secrets masked before it reaches a file.
+	// It is called before Sanitize with each write (or a record with
+	// JSONLines) and must not modify or retain b. A partial write is
+	// reported like with Sanitize.
+	// If Redact == nil, writes are not redacted.
+	Redact func(b []byte) []byte
+	// Sample drops lines before Redact, e.g. keeping 1 in 100 debug lines.
+	// A partial write is reported like with Sanitize.
+	// If Sample == nil, all lines are written.
+	Sample *Sampler
+	// Multiline groups lines starting with a space or a tab, e.g. lines of
+	// a stack trace, with a preceding line into a record, so that rotation
+	// and SplitWrites never split it. The last record is held until a next
+	// one starts or the file is flushed, synced or closed, so continuation
+	// lines written after Sync start a record of their own. It is ignored
+	// with JSONLines.
+	// See Groups to read records of a rotation set.
+	Multiline bool
+	// Encoding defines an encoding of files. A byte order mark is written
+	// before Header, so it starts every file. Readers of this package skip
+	// marks and decode UTF-16LE. Defaults to UTF8.
+	Encoding Encoding
+	// Promote creates a new file after rotation at a temporary name and
+	// renames it to the name of a file once Header is written, so that
+	// tailers never see a file without a header. On Linux, the file is
+	// created anonymously instead. It is ignored with CopyTruncate.
+	Promote bool
+	// UseUTC uses UTC instead of local time for Timestamp names, Interval
+	// boundaries, Blackout windows, Stamp and audit records, so that hosts
+	// in different time zones produce comparable files.
+	UseUTC bool
+	// Anchored counts Interval from the first write to a current file
+	// instead of aligning boundaries to midnight. Time of the first write
+	// is kept in a hidden file .<name>.anchor next to a file, so that it
+	// survives restarts.
+	Anchored bool
+	// CleanOrphans removes artifacts of crashed runs on Wrap: temporary
+	// files of compression, LinkDuplicates, Manifest and Promote, directory
+	// probes older than a minute and empty duplicates of rotated files,
+	// e.g. a.1 next to a.1.gz. OrphanRemoved is emitted for each of them.
+	// If cleaning fails, Wrap closes f.
+	CleanOrphans bool
+	// SlowRotation emits RotationSlow once a rotation takes longer, so
+	// that e.g. a hung rename on a network file system is told from
+	// a deadlock. If SlowRotation == 0, no event is emitted.
+	SlowRotation time.Duration
+	// RenameTimeout bounds time of renames of rotated files. Once it is
+	// exceeded, remaining renames are skipped, writes continue to
+	// a current file and rotation fails with ErrRenameTimeout. A next
+	// rotation compacts the chain instead of removing the oldest file.
+	// A rename in progress is not interrupted and the first one is always
+	// done, so that the chain is compacted eventually. If
+	// RenameTimeout == 0, renames are not bounded.
+	RenameTimeout time.Duration
+	// LinkDuplicates replaces a rotated file with a hard link to
+	// the previous one if their content is identical, e.g. of
+	// a heartbeat-only file rotated by Interval, so that it is stored once.
+	// Files are compared by SHA-256 sums after compression and share
+	// modification time of the newer one then. A file is kept as is if
+	// a link fails, e.g. is not supported by a file system. It is ignored
+	// with IDs and ProtectImmutable.
+	LinkDuplicates bool
+}
+
+// now returns current time in a location of c.
+func (c Config) now() time.Time {
+	return c.in(now())
+}
+
+// in returns t in a location of c.
+func (c Config) in(t time.Time) time.Time {
+	if c.UseUTC {
+		return t.UTC()
+	}
+	return t
+}
+
+func (c Config) flag() int {
+	if c.Flag == 0 {
+		return OpenFlag
+	}
+	return c.Flag
+}
+
+func (c Config) perm() os.FileMode {
+	if c.Perm == 0 {
+		return OpenPerm
+	}
+	return c.Perm
+}
+
+// WithoutLock returns a copy of c with NoLock set.
+func (c Config) WithoutLock() Config {
+	c.NoLock = true
+	return c
+}
+
+// Quota defines what happens to writes exceeding Config.TotalBytes.
+type Quota int
+
+// Quota policies.
+const (
+	// QuotaIgnore writes anyway, so a current file grows without bound.
+	QuotaIgnore Quota = iota
+	// QuotaError fails writes with ErrQuotaExceeded.
+	QuotaError
+	// QuotaDrop drops writes and reports them as successful.
+	// WritesDropped event is emitted once writes pass again.
+	QuotaDrop
+)
+
+var quotas = map[Quota]string{
+	QuotaIgnore: "ignore",
+	QuotaError:  "error",
+	QuotaDrop:   "drop",
+}
+
+func (q Quota) String() string {
+	if s, ok := quotas[q]; ok {
+		return s
+	}
+	return fmt.Sprintf("Quota(%d)", int(q))
+}
+
+// Method defines how a current file is rotated.
+type Method int
+
+// Rotation methods.
+const (
+	// Rename renames a current file and opens a new one.
+	Rename Method = iota
+	// CopyTruncate copies a current file to a rotated name and truncates it.
+	// A file descriptor and inode are preserved, so no reopen happens.
+	// On btrfs and XFS the copy is a reflink, which is instant.
+	// The file must implement Truncate method like *os.File does.
+	//
+	// A hard link can not replace the copy, as the link shares an inode with
+	// a current file and would be truncated as well.
+	CopyTruncate
+)
+
+var methods = map[Method]string{
+	Rename:       "rename",
+	CopyTruncate: "copytruncate",
+}
+
+func (m Method) String() string {
+	if s, ok := methods[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("Method(%d)", int(m))
 }
 
 // File is an interface compatible with *os.File.
@@ -55,88 +418,499 @@ type File interface {
 	WriteString(string) (int, error)
 }
 
-// Wrap wraps f with Rotator instance and returns File.
-func Wrap(f File, c Config) (File, error) {
-	r, err := New(f, c.Count)
+// Wrap wraps f with Rotator instance and returns Rotor.
+//
+// If no policy of c can trigger rotation or change a write, e.g. with
+// Config{}, writes go straight to f without locking. A file can still be
+// rotated by Rotor.Rotate, but RotationInfo.First and Last are zero then.
+func Wrap(f File, c Config) (*Rotor, error) {
+	v, err := wrap(f, c)
+	if v == nil {
+		return nil, err
+	}
+	return &Rotor{v}, err
+}
+
+func wrap(f File, c Config) (File, error) {
+	r, err := newRotator(f, c)
 	if err != nil && err != ErrNotSupported {
 		return nil, err
 	}
 	var size int64
+	var mtime time.Time
 	{
 		v, err := f.Stat()
 		if err != nil {
 			return nil, err
 		}
 		size = v.Size()
+		if size > 0 {
+			mtime = c.in(v.ModTime())
+		}
 	}
 	var mu mutex
 	{
-		if c.Lock {
+		if !c.NoLock || c.Watch > 0 {
 			mu = new(sync.Mutex)
 		} else {
 			mu = new(noMutex)
 		}
 	}
 	ff := file{
-		w:     f,
-		r:     r,
-		mu:    mu,
-		bytes: c.Bytes,
-		n:     size,
+		w:      f,
+		r:      r,
+		c:      c,
+		mu:     mu,
+		limit:  newRateLimit(c.MaxRotations, c.RotationPeriod),
+		dedup:  newDedup(c.Dedup, c.DedupEqual),
+		bucket: newBucket(c.BytesPerSec),
+		done:   make(chan struct{}),
+		last:   mtime,
+		raw:    c.passthrough(),
+	}
+	ff.resize(size)
+	if a, ok := r.(anchorer); ok && c.Anchored && size > 0 {
+		ff.first = a.Anchor()
+	}
+	ff.setCurrent(f)
+	if v, ok := r.(cleaner); ok && c.CleanOrphans {
+		err := v.CleanOrphans(func(name string, err error) {
+			ff.emit(Event{Type: OrphanRemoved, Filename: name, Err: err})
+		})
+		if err != nil {
+			_ = ff.Close()
+			return nil, err
+		}
+	}
+	if v, ok := r.(initializer); ok && c.Promote {
+		v.SetInit(ff.prepare)
+	}
+	if size == 0 {
+		if err := ff.header(); err != nil {
+			_ = ff.Close()
+			return nil, err
+		}
+	}
+	if c.Watch > 0 {
+		go ff.watch(c.Watch)
+	}
+	if c.Shards > 0 {
+		return newSharded(&ff, c.Shards), err
 	}
 	return &ff, err
 }
 
 type file struct {
-	w     File
-	r     Rotator
-	mu    mutex
-	bytes int64
-	n     int64
+	w       File
+	cur     atomic.Value // current, see setCurrent
+	r       Rotator
+	c       Config
+	mu      mutex
+	limit   *rateLimit
+	limited bool
+	dedup   *dedup
+	bucket  *bucket
+	dropped int64
+	partial []byte    // a held record, see writeRecords and writeGroups
+	midLine bool      // a current line is not ended, see Config.Stamp
+	lineLen int       // length of a current line, see Config.Sanitize
+	capped  bool      // a current line is capped, see Config.Sanitize
+	primed  []byte    // a header written by prepare, see Config.Promote
+	batch   []byte    // a buffer of WriteBatch
+	n       int64     // size of a current file
+	counted int64     // size of a current file by Config.SizeFunc
+	hash    hash.Hash // nil unless all content is hashed, see Config.Hash
+	total   int64
+	closed  bool
+	done    chan struct{}
+	first   time.Time // first write to a current file
+	last    time.Time // last write to a current file
+	from    time.Time // start of an interval ending at until, see due
+	until   time.Time
+	infos   []RotationInfo
+	frozen  int          // number of WithFrozenFile calls
+	hold    sync.RWMutex // held by WithFrozenFile for ScheduleRotation
+	latency latency      // see Rotor.Stats
+	raw     bool         // see Config.passthrough
+}
+
+// Metadata methods do not lock, so they are safe to call with Config.NoLock
+// set and do not wait for a write or rotation to complete.
+func (f *file) Fd() uintptr                { return f.current().fd }
+func (f *file) Name() string               { return f.current().Name() }
+func (f *file) Stat() (os.FileInfo, error) { return f.current().Stat() }
+
+// current is a File held by atomic.Value, which requires a single
+// concrete type to be stored. fd is cached, as Fd of *os.File races
+// with Close during rotation. seq tells files apart, see writeRaw.
+type current struct {
+	File
+	fd  uintptr
+	seq uint64
+}
+
+// current returns a current file for metadata methods.
+func (f *file) current() current {
+	return f.cur.Load().(current)
 }
 
-func (f *file) Fd() uintptr                { return f.w.Fd() }
-func (f *file) Name() string               { return f.w.Name() }
-func (f *file) Stat() (os.FileInfo, error) { return f.w.Stat() }
+// setCurrent replaces a current file. It must be called under the lock.
+func (f *file) setCurrent(w File) {
+	var seq uint64
+	if v, ok := f.cur.Load().(current); ok {
+		seq = v.seq + 1
+	}
+	f.w = w
+	f.cur.Store(current{w, w.Fd(), seq})
+}
 
 func (f *file) Sync() (err error) {
 	f.mu.Lock()
-	err = f.w.Sync()
+	err = f.flush()
+	if err == nil {
+		err = f.w.Sync()
+	}
+	if err == nil {
+		err = f.syncRotated()
+	}
 	f.mu.Unlock()
 	return
 }
 
 func (f *file) Write(b []byte) (n int, err error) {
+	if f.raw {
+		return f.writeRaw(b)
+	}
 	f.mu.Lock()
-	defer f.mu.Unlock()
-	rerr := f.rotate()
-	n, err = f.w.Write(b)
-	if err == nil {
+	n, err = f.write(b)
+	total := f.total
+	infos := f.infos
+	f.infos = nil
+	f.mu.Unlock()
+	if f.c.OnWrite != nil {
+		f.c.OnWrite(n, total)
+	}
+	f.onRotate(infos)
+	return
+}
+
+func (f *file) write(b []byte) (int, error) {
+	if f.c.JSONLines {
+		return f.writeRecords(b)
+	}
+	if f.c.Multiline {
+		return f.writeGroups(b)
+	}
+	return f.message(b)
+}
+
+// message writes b as a single message.
+func (f *file) message(b []byte) (int, error) {
+	if f.c.Sample == nil && f.c.Redact == nil && !f.c.Sanitize.enabled() {
+		return f.send(b)
+	}
+	s := b
+	if f.c.Sample != nil {
+		if s = f.c.Sample.Filter(s); len(s) == 0 {
+			return len(b), nil
+		}
+	}
+	if f.c.Redact != nil {
+		s = f.c.Redact(s)
+	}
+	if f.c.Sanitize.enabled() {
+		s = f.sanitize(s)
+	}
+	n, err := f.send(s)
+	if n == len(s) || n > len(b) {
+		n = len(b) // sanitized data is longer or shorter than b
+	}
+	return n, err
+}
+
+// send writes a message after Config.Sample, Config.Redact and
+// Config.Sanitize.
+func (f *file) send(b []byte) (int, error) {
+	if f.dedup != nil {
+		t := now()
+		if f.dedup.Repeat(b, t) {
+			return len(b), nil
+		}
+		if err := f.flushDedup(); err != nil {
+			return 0, err
+		}
+		f.dedup.Reset(b, t)
+	}
+	if f.bucket != nil && !f.bucket.Take(len(b), now()) {
+		f.dropped++
+		return len(b), nil
+	}
+	if f.c.Stamp != "" {
+		return f.timestamp(b)
+	}
+	return f.split(b)
+}
+
+// flushDedup writes a message for a pending run of duplicates.
+// Rotation errors are skipped as rotation is retried on next write.
+func (f *file) flushDedup() error {
+	if f.dedup == nil {
+		return nil
+	}
+	msg := f.dedup.Flush()
+	if msg == nil {
+		return nil
+	}
+	n, err := f.put(msg)
+	if n < len(msg) {
+		return err
+	}
+	return nil
+}
+
+// put writes b to the current file rotating it beforehand if needed.
+func (f *file) put(b []byte) (int, error) {
+	if f.c.Encoding == UTF16LE {
+		return f.putEncoded(b)
+	}
+	return f.putRaw(b)
+}
+
+// putRaw is put of data in Config.Encoding. n is a number of bytes of b
+// written or dropped by QuotaDrop. err is a write error, ErrQuotaExceeded
+// or, with Config.RotationErrors, *Error of a failed rotation if all of b
+// is written.
+func (f *file) putRaw(b []byte) (n int, err error) {
+	t := f.c.now()
+	rerr := f.rotate(t)
+	if !f.fits(len(b)) {
+		switch f.c.Quota {
+		case QuotaError:
+			return 0, ErrQuotaExceeded
+		case QuotaDrop:
+			f.dropped++
+			return len(b), nil
+		}
+	}
+	if rerr != nil {
+		if _, ok := rerr.(*Error); !ok {
+			rerr = &Error{Filename: f.w.Name(), Err: rerr}
+		}
+		f.emit(Event{Type: RotationFailed, Filename: f.w.Name(), Err: rerr})
+	}
+	n, err = writeFull(f.w, b)
+	if err == nil && f.c.RotationErrors {
 		err = rerr
 	}
-	f.n += int64(n)
+	if n > 0 {
+		f.last = t
+		if f.first.IsZero() {
+			f.first = f.last
+			f.anchor(f.first)
+		}
+	}
+	f.count(b[:n])
+	f.total += int64(n)
+	if f.dropped > 0 {
+		f.emit(Event{Type: WritesDropped, Filename: f.w.Name(), N: f.dropped})
+		f.dropped = 0
+	}
 	return
 }
 
+// maxRetries is a number of retries of a write interrupted without progress.
+const maxRetries = 8
+
+// writeFull writes b to w retrying short writes failed with retriable
+// errors, so that b is either written entirely or an error is returned.
+func writeFull(w io.Writer, b []byte) (n int, err error) {
+	for retries := 0; ; {
+		var m int
+		m, err = w.Write(b[n:])
+		n += m
+		if n == len(b) || !retriable(err) {
+			return
+		}
+		if m > 0 {
+			retries = 0
+		} else if retries++; retries > maxRetries {
+			return
+		}
+	}
+}
+
+// retriable reports whether a write failed with err may be continued.
+func retriable(err error) bool {
+	if v, ok := err.(*os.PathError); ok {
+		err = v.Err
+	}
+	return err == syscall.EINTR || err == syscall.EAGAIN
+}
+
 func (f *file) WriteString(s string) (int, error) {
 	return f.Write([]byte(s))
 }
 
 func (f *file) Close() error {
-	return f.w.Close()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.stop()
+	if cerr := f.w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// stop stops rotation and flushes held writes. It must be called under
+// the lock. See Close and Rotor.Detach.
+func (f *file) stop() error {
+	if !f.closed {
+		f.closed = true
+		close(f.done)
+	}
+	err := f.flushDedup()
+	if rerr := f.flushRecords(); err == nil {
+		err = rerr
+	}
+	if v, ok := f.r.(waiter); ok {
+		if werr := v.wait(); err == nil {
+			err = werr
+		}
+	}
+	if serr := f.syncRotated(); err == nil {
+		err = serr
+	}
+	return err
 }
 
-func (f *file) rotate() (err error) {
-	if f.bytes <= 0 || f.n < f.bytes {
+// rotate rotates a current file if it is due at t. A check runs on every
+// write, so it must not allocate unless a file is due.
+func (f *file) rotate(t time.Time) (err error) {
+	if !f.due(t) {
+		return nil
+	}
+	// A file might have been truncated by external tools.
+	if v, err := f.w.Stat(); err == nil && f.truncated(v.Size()) && !f.due(t) {
+		return nil
+	}
+	if inWindows(f.c.Blackout, t) || f.frozen > 0 {
 		return nil
 	}
-	f.w, err = f.r.Rotate()
+	if !f.limit.Allow(t) {
+		if !f.limited {
+			f.limited = true
+			f.emit(Event{Type: RotationLimited, Filename: f.w.Name()})
+		}
+		return nil
+	}
+	if _, err = f.force(t); err == errPending {
+		return nil // writes continue to a rotated file
+	}
+	return
+}
+
+// force rotates a current file at t regardless of policies and returns
+// RotationInfo of a sealed file.
+func (f *file) force(t time.Time) (info RotationInfo, err error) {
+	size := f.size()
+	var w File
+	var ferr error
+	if v, ok := f.r.(stager); ok && f.c.SlowRotation > 0 {
+		name := f.w.Name()
+		timer := time.AfterFunc(f.c.SlowRotation, func() {
+			f.emit(Event{Type: RotationSlow, Filename: name, Stage: v.Stage()})
+		})
+		defer timer.Stop()
+	}
+	if v, ok := f.r.(spanner); ok {
+		v.span(f.first, f.last)
+	}
+	start := time.Now()
+	var d time.Duration
+	if v, ok := f.r.(phased); ok {
+		w, err = v.rotate()
+		d = time.Since(start)
+		if err == nil {
+			// A new file is published before finish closes the old one.
+			f.setCurrent(w)
+			ferr = v.finish()
+		}
+	} else {
+		w, err = f.r.Rotate()
+		d = time.Since(start)
+	}
+	if err == errPending {
+		return
+	}
+	if w != f.w {
+		f.setCurrent(w)
+	}
 	if err == nil {
-		f.n = 0
+		f.latency.add(d)
+		info = f.sealed(size, d)
+		f.resize(0)
+		err = f.header()
+		if err == nil {
+			err = ferr
+		}
+		f.limited = false
+		f.limit.Add(t)
 	}
 	return
 }
 
+// count counts b written to a current file.
+func (f *file) count(b []byte) {
+	f.n += int64(len(b))
+	if f.hash != nil {
+		_, _ = f.hash.Write(b)
+	}
+	if f.c.SizeFunc != nil {
+		f.counted += f.c.SizeFunc(b)
+	} else {
+		f.counted += int64(len(b))
+	}
+}
+
+// resize sets a size of a current file, e.g. after rotation. Content of
+// a non-empty file is unknown, so it is not hashed.
+func (f *file) resize(n int64) {
+	f.n = n
+	f.counted = n
+	f.hash = nil
+	if n == 0 && f.c.Hash != nil {
+		f.hash = f.c.Hash()
+	}
+}
+
+// due reports whether a current file must be rotated at t by Config.Bytes
+// or Config.Interval.
+func (f *file) due(t time.Time) bool {
+	if f.c.Bytes > 0 && f.counted >= f.c.Bytes {
+		return true
+	}
+	if f.c.Interval <= 0 || f.n == 0 {
+		return false
+	}
+	start := f.first
+	if start.IsZero() {
+		start = f.last // a file was not empty on Wrap
+	}
+	if start.IsZero() {
+		return false
+	}
+	if !start.Equal(f.from) {
+		f.from, f.until = start, f.boundary(start)
+	}
+	return !t.Before(f.until)
+}
+
+func (f *file) emit(e Event) {
+	if f.c.OnEvent != nil {
+		f.c.OnEvent(e)
+	}
+}
+
 // Rotator is an interface for file rotation.
 type Rotator interface {
 	Rotate() (File, error)
@@ -155,7 +929,19 @@ type dirnamer interface {
 }
 
 // New returns Rotator for f.
-func New(f File, count int64) (r Rotator, err error) {
+func New(f File, count int64) (Rotator, error) {
+	return newRotator(f, Config{Count: count})
+}
+
+// truncater is implemented by files which can be truncated, e.g. *os.File.
+type truncater interface {
+	Truncate(size int64) error
+}
+
+func newRotator(f File, c Config) (r Rotator, err error) {
+	if err = checkPatterns(c.KeepPatterns, c.Naming); err != nil {
+		return nil, err
+	}
 	var root string
 	if v, ok := f.(dirnamer); ok {
 		root = v.Dirname()
@@ -180,37 +966,100 @@ func New(f File, count int64) (r Rotator, err error) {
 	{
 		base := filepath.Base(f.Name())
 		// save syscall while a single file
-		if count < 1 {
+		if c.Count < 1 {
 			names = []string{base}
 			goto AFTER_NAMES
 		}
-		v, err := List(root, base)
-		if err != nil {
+		if names, err = listNames(root, base, c); err != nil {
 			return nil, err
 		}
-		if len(v) < 1 {
-			panic("must contain current file")
-		}
-		names = make([]string, count)
-		copy(names, v)
 	}
 AFTER_NAMES:
-	r = &rotator{
+	if c.Bytes > 0 || c.Interval > 0 {
+		if err := probe(root, names[0]); err != nil {
+			return nil, err
+		}
+	}
+	if c.Method == CopyTruncate {
+		if _, ok := f.(truncater); !ok {
+			return nil, fmt.Errorf("rotate: %s: %s requires Truncate", f.Name(), c.Method)
+		}
+	}
+	gid, err := lookupGroup(c.Group)
+	if err != nil {
+		return nil, err
+	}
+	rr := &rotator{
+		gid:   gid,
 		f:     f,
+		c:     c,
 		mode:  mode,
 		root:  root,
 		name:  names[0],
 		names: names,
 	}
+	if c.Lumberjack {
+		rr.legacy, err = listLumberjack(root, names[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.TotalBytes > 0 {
+		rr.usage()
+	}
+	if c.Manifest {
+		rr.manifest, err = loadManifest(rr.abs(manifestName(rr.name)))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.IDs {
+		if err = rr.identify(); err != nil {
+			return nil, err
+		}
+	}
+	r = rr
 	return
 }
 
 type rotator struct {
 	f     File
+	c     Config
 	mode  os.FileMode
 	root  string
 	name  string
 	names []string
+	used  int64 // size of rotated files
+	// unsynced is a number of rotations since the last SyncRotated.
+	unsynced int
+	manifest *manifest // see Config.Manifest
+	first    time.Time // of writes to a current file, see span
+	last     time.Time
+	id       string    // ID of a current file, see Config.IDs
+	sealedID string    // ID of the last rotated file
+	// old is a file replaced by reopen. It is closed by release once
+	// a new file is published, so that writes of Config.passthrough
+	// racing with rotation do not fail on a closed file.
+	old File
+	// pending is set when a file was renamed, but a new one could not be
+	// created. Reopen is retried with backoff.
+	pending bool
+	backoff time.Duration
+	retryAt time.Time
+	gid     int    // see Config.Group
+	acl     []byte // an ACL of a rotated file, see Config.CopyACL
+	aclErr  error
+	zip     *compression // in progress, see Config.Compress
+	zipErr  error        // of the last compression, see finish
+	// auditErr is an error of auditing a removal by rename, see finish.
+	auditErr error
+	// init initializes a new file, see Config.Promote.
+	init func(io.Writer) error
+	// stage is a stage of rotation in progress, see Config.SlowRotation.
+	stage atomic.Value
+	// legacy are rotated files named by other tools, from the newest to
+	// the oldest. They are older than files of a chain.
+	legacy []string
 }
 
 func (r *rotator) abs(name string) string {
@@ -218,29 +1067,194 @@ func (r *rotator) abs(name string) string {
 }
 
 func (r *rotator) Rotate() (File, error) {
+	f, err := r.rotate()
+	if err != nil {
+		return f, err
+	}
+	return f, r.finish()
+}
+
+// phased is implemented by rotators which split Rotate into rotation itself
+// and work after it, e.g. compression. Errors of the latter do not cancel
+// rotation.
+type phased interface {
+	rotate() (File, error)
+	finish() error
+}
+
+// finish does work after a file is rotated and a new one is published.
+func (r *rotator) finish() (err error) {
+	r.release()
+	if r.aclErr != nil {
+		err = &Error{Filename: r.name, Err: r.aclErr}
+		r.aclErr = nil
+	}
+	if err == nil {
+		err = r.zipErr
+	}
+	if err == nil {
+		err = r.auditErr
+	}
+	r.zipErr, r.auditErr = nil, nil
+	if r.c.SyncRotated {
+		r.unsynced++
+	}
+	r.enter("seal")
+	if serr := r.seal(); err == nil {
+		err = serr
+	}
+	if r.c.IDs {
+		if ierr := r.reidentify(); err == nil {
+			err = ierr
+		}
+	}
+	r.enter("compress")
+	if cerr := r.compress(); err == nil {
+		err = cerr
+	}
+	// A compressed file is linked and protected by compressed.
+	if r.zip == nil {
+		r.enter("link")
+		if lerr := r.link(); err == nil {
+			err = lerr
+		}
+		r.enter("protect")
+		if perr := r.protect(); err == nil {
+			err = perr
+		}
+	}
+	r.enter("retain")
+	if rerr := r.retain(); err == nil {
+		err = rerr
+	}
+	r.enter("")
+	return
+}
+
+func (r *rotator) rotate() (File, error) {
+	r.enter("compress")
+	r.zipErr = r.compressed()
+	if r.pending {
+		return r.f, r.retryReopen()
+	}
+	if r.c.SyncOnRotate {
+		r.enter("sync")
+		if err := r.f.Sync(); err != nil {
+			return r.f, &Error{Filename: r.name, Err: err}
+		}
+	}
+	if r.c.Method == CopyTruncate {
+		return r.f, r.copyTruncate()
+	}
+	r.acl = nil
+	if r.c.CopyACL {
+		r.acl, r.aclErr = getACL(r.f)
+	}
 	err := r.rename()
 	if err == nil {
-		// TODO: If error, rename file back & remove obsolete `<name>.0` from r.names.
-		err = r.reopen()
+		r.enter("reopen")
+		if err = r.reopen(); err != nil {
+			// Writes continue to the renamed file until reopen succeeds.
+			r.pending = true
+			r.backoff = 0
+			r.retryAt = time.Time{}
+			err = r.failReopen(err)
+		}
 	}
 	return r.f, err
 }
 
+// copyTruncate copies a current file to a rotated name and truncates it.
+func (r *rotator) copyTruncate() error {
+	// A single file is only truncated.
+	if len(r.names) > 1 {
+		if err := r.rename(); err != nil {
+			return err
+		}
+	}
+	r.enter("truncate")
+	if err := r.f.(truncater).Truncate(0); err != nil {
+		return &Error{Filename: r.name, Err: err}
+	}
+	if v, ok := r.f.(io.Seeker); ok {
+		_, _ = v.Seek(0, io.SeekStart)
+	}
+	return nil
+}
+
+func (r *rotator) Reopen() (File, error) {
+	err := r.reopen()
+	r.release()
+	return r.f, err
+}
+
+// copy copies a file at src to a new file at dst. A reflink is tried first,
+// then io.Copy falls back to copy_file_range/sendfile where available.
+func (r *rotator) copy(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := createFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, r.mode, r.gid)
+	if err != nil {
+		return err
+	}
+	if clone(out, in) != nil {
+		_, err = io.Copy(out, in)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = remove(dst)
+	}
+	return err
+}
+
 func (r *rotator) reopen() error {
 	name := r.abs(r.name)
-	f, err := os.OpenFile(name, OpenFlag, r.mode)
+	flag := r.c.flag() | os.O_CREATE // a file is renamed or removed
+	var f *os.File
+	var err error
+	if r.init != nil {
+		f, err = createInit(name, flag, r.mode, r.gid, r.init)
+	} else {
+		f, err = createFile(name, flag, r.mode, r.gid)
+	}
 	if err != nil {
 		return err
 	}
-	// TODO: Handle error.
-	_ = r.f.Close()
+	if r.acl != nil {
+		r.aclErr = setACL(f, r.acl)
+	}
+	r.release()
+	r.old = r.f
 	r.f = f
 	return nil
 }
 
+// release closes a file replaced by reopen.
+func (r *rotator) release() {
+	if r.old != nil {
+		// TODO: Handle error.
+		_ = r.old.Close()
+		r.old = nil
+	}
+}
+
 func (r *rotator) rename() (err error) {
-	if s := r.names[len(r.names)-1]; s != "" {
-		err = os.Remove(r.abs(s))
+	// A chain with a gap, e.g. left by Config.RenameTimeout, is compacted:
+	// files up to the gap are shifted and none is removed.
+	if gapped(r.names) {
+		// compacted below
+	} else if s := r.names[len(r.names)-1]; s != "" && r.kept(s) {
+		r.names[len(r.names)-1] = ""
+	} else if s != "" {
+		err = r.unprotect(s, false)
+		if err == nil {
+			err = remove(r.abs(s))
+		}
 		if err != nil {
 			return &Error{
 				Filename: s,
@@ -248,19 +1262,54 @@ func (r *rotator) rename() (err error) {
 			}
 		}
 		r.names[len(r.names)-1] = ""
+		r.moveGeneration(s, "")
+		// A file is removed anyway, so rotation goes on. See finish.
+		r.auditErr = r.audit("remove", byCount, s)
 	}
 
-	names := shift(r.names)
+	if r.c.Naming == Timestamp {
+		r.enter("rename")
+		return r.stamp()
+	}
+
+	g := firstEmpty(r.names)
+	names := shift(r.names, r.c.Naming)
 
+	var deadline time.Time
+	if r.c.RenameTimeout > 0 {
+		deadline = time.Now().Add(r.c.RenameTimeout)
+	}
 	var i int
-	for i = len(r.names) - 1; i >= 0; i-- {
+	for i = g - 1; i >= 0; i-- {
+		r.enter(fmt.Sprintf("rename %d of %d", g-i, g))
+		if i < g-1 && !deadline.IsZero() && time.Now().After(deadline) {
+			err = &Error{Filename: r.names[i], Err: ErrRenameTimeout}
+			break
+		}
 		if r.names[i] == "" {
 			continue
 		}
-		err = os.Rename(
-			r.abs(r.names[i]),
-			r.abs(names[i]),
-		)
+		op := rename
+		if i == 0 && r.c.Method == CopyTruncate {
+			op = r.copy
+		}
+		if i > 0 {
+			err = r.unprotect(r.names[i], true)
+		}
+		if err == nil {
+			err = op(
+				r.abs(r.names[i]),
+				r.abs(names[i]),
+			)
+		}
+		if err == nil && i > 0 {
+			r.moveGeneration(r.names[i], names[i])
+		}
+		if err == nil && i > 0 && r.c.Protect == ProtectImmutable {
+			if err = setImmutable(r.abs(names[i]), true); err == ErrNotSupported {
+				err = nil
+			}
+		}
 		if err != nil {
 			err = &Error{
 				Filename: r.names[i],
@@ -270,85 +1319,213 @@ func (r *rotator) rename() (err error) {
 		}
 	}
 
-	if i == -1 { // renamed all
-		copy(r.names[1:], names)
-	} else {
-		// leave last empty (removed)
-		copy(r.names[i+1:len(names)-2], names[i+1:])
+	// Files after i are shifted, leaving a gap at i+1 unless all are.
+	for j := g - 1; j > i; j-- {
+		r.names[j+1] = names[j]
+	}
+	if i >= 0 {
+		r.names[i+1] = ""
 	}
 	return
 }
 
-// shift returns a list of names with incremented rotation suffix.
-// names must contain at list one item.
+// firstEmpty returns an index of the first empty name of rotated files
+// in a chain or the last index if there is none.
+func firstEmpty(names []string) int {
+	for i := 1; i < len(names); i++ {
+		if names[i] == "" {
+			return i
+		}
+	}
+	return len(names) - 1
+}
+
+// gapped reports whether a chain has an empty name followed by a non-empty
+// one.
+func gapped(names []string) bool {
+	empty := false
+	for _, s := range names[1:] {
+		if s == "" {
+			empty = true
+		} else if empty {
+			return true
+		}
+	}
+	return false
+}
+
+// shift returns a list of names with incremented rotation suffix formatted
+// with scheme. names must contain at list one item.
 //
 //     [a]     -> [a.1]
 //     [a a.1] -> [a.1 a.2]
 //
-func shift(names []string) []string {
+func shift(names []string, scheme NamingScheme) []string {
 	t := make([]string, len(names))
 	for i, s := range names {
 		if s == "" {
 			break
 		}
-		base, n := Split(s)
-		t[i] = fmt.Sprintf("%s.%d", base, n+1)
+		base, n, _, ext := parse(s)
+		t[i] = scheme.format(base, n+1, time.Time{}, ext)
 	}
 	return t
 }
 
-// SuffixRe is a pattern of rotation counter suffix.
-const SuffixRe = `(\.[1-9]+)?$`
+// SuffixRe is a pattern of rotation suffix: a counter (optionally
+// zero-padded) or a timestamp with an optional disambiguator. A rotated
+// file may have a compression extension.
+const SuffixRe = `(\.(0*[1-9][0-9]*|[0-9]{8}T[0-9]{6}(-[1-9][0-9]*)?)(\.gz)?)?$`
 
 var suffixRe = regexp.MustCompile(SuffixRe)
 
 // Split splits name into base part and rotation counter.
 // When name cannot be splitted, base equals name.
+// For a timestamp suffix, n is 0. See ParseName.
 func Split(name string) (base string, n int64) {
+	base, n, t, _ := parse(name)
+	if !t.IsZero() {
+		n = 0
+	}
+	return
+}
+
+// parse is like Split, but also returns a timestamp and a compression
+// extension. For a timestamp suffix, n is a disambiguator of timestamps
+// within the same second.
+//
+// A timestamp is a wall clock of a name in UTC, whichever location a name
+// is written in, so that names compare in order without gaps and repeats
+// of daylight saving time. See ParseNameIn for time in a location.
+func parse(name string) (base string, n int64, t time.Time, ext string) {
 	v := suffixRe.FindStringSubmatch(name)
 	if v == nil || v[1] == "" {
 		base = name
 		return
 	}
 	base = strings.TrimSuffix(name, v[1])
-	n, err := strconv.ParseInt(v[1][1:], 10, 64) // without dot
+	ext = v[4]
+	if len(v[2]) >= len(TimestampFormat) && v[2][8] == 'T' {
+		var err error
+		t, err = time.Parse(TimestampFormat, v[2][:len(TimestampFormat)])
+		if err == nil && v[3] != "" {
+			n, err = strconv.ParseInt(v[3][1:], 10, 64)
+		}
+		if err != nil {
+			base = name // not a valid time
+			n, t, ext = 0, time.Time{}, ""
+		}
+		return
+	}
+	n, err := strconv.ParseInt(v[2], 10, 64)
 	if err != nil {
 		panic("invalid suffix regexp")
 	}
 	return
 }
 
-// List returns a sorted list of names of existing files which end with SuffixRe.
-// If name exists, it is the first item in result.
+// List returns a list of names of existing files which end with SuffixRe,
+// sorted from the newest to the oldest: by rotation counter, then by
+// timestamp. If name exists, it is the first item in result.
 func List(root, name string) ([]string, error) {
+	return scan(root, name, 0, 0)
+}
+
+// scanBatch is a number of directory entries read at once.
+const scanBatch = 256
+
+// scan is like List, but returns at most max names and reads at most limit
+// directory entries. If max or limit is 0, it is not bounded.
+// Only matching entries are stat'ed.
+func scan(root, name string, max, limit int) ([]string, error) {
 	base := filepath.Base(name)
 	re, err := toRegexp(base)
 	if err != nil {
 		return nil, err
 	}
 
+	d, err := os.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
 	var names []string
-	err = filepath.Walk(root, func(wpath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() && wpath != root {
-			return filepath.SkipDir
+	for read := 0; limit <= 0 || read < limit; {
+		n := scanBatch
+		if limit > 0 && limit-read < n {
+			n = limit - read
 		}
-		s := filepath.Base(info.Name())
-		if re.MatchString(s) {
+		v, err := d.Readdirnames(n)
+		read += len(v)
+		for _, s := range v {
+			if s == base || !re.MatchString(s) {
+				continue
+			}
+			if info, err := os.Lstat(filepath.Join(root, s)); err != nil || info.IsDir() {
+				continue
+			}
 			names = append(names, s)
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	sort.Strings(names)
+	sort.SliceStable(names, func(i, j int) bool {
+		return newer(names[i], names[j])
+	})
+	if info, err := os.Lstat(filepath.Join(root, base)); err == nil && !info.IsDir() {
+		names = append([]string{base}, names...)
+	}
+	if max > 0 && len(names) > max {
+		names = names[:max]
+	}
+	return names, nil
+}
+
+// listNames returns names of a rotation set of base for c: a current file
+// and Config.Count-1 rotated ones, which may be empty.
+func listNames(root, base string, c Config) ([]string, error) {
+	var v []string
+	if c.SkipScan && c.Naming != Timestamp {
+		v = chain(root, base, c.Count, c.Naming)
+	} else {
+		var err error
+		if v, err = scan(root, base, int(c.Count), c.MaxScan); err != nil {
+			return nil, err
+		}
+	}
+	if len(v) < 1 || v[0] != base {
+		v = append([]string{base}, v...) // removed by external tools
+	}
+	names := make([]string, c.Count)
+	copy(names, v)
 	return names, nil
 }
 
+// chain returns names of existing files of a Numeric or ZeroPadded chain
+// of name with count files, including a current one. A file may be
+// compressed.
+func chain(root, name string, count int64, scheme NamingScheme) []string {
+	base := filepath.Base(name)
+	names := []string{base}
+	for i := int64(1); i < count; i++ {
+		s := scheme.format(base, i, time.Time{}, "")
+		for _, ext := range []string{"", gzipExt} {
+			if _, err := os.Lstat(filepath.Join(root, s+ext)); err == nil {
+				names = append(names, s+ext)
+				break
+			}
+		}
+	}
+	return names
+}
+
 func toRegexp(name string) (*regexp.Regexp, error) {
 	name = strings.Replace(name, `.`, `\.`, -1)
 	p, err := regexp.Compile(`^` + name + SuffixRe)
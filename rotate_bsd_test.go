@@ -0,0 +1,71 @@
+// +build darwin freebsd netbsd
+
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestDirname(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	f, err := os.Open(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s, err := rotate.Dirname(f.Fd())
+	if err == rotate.ErrNotSupported {
+		t.Skip(err) // e.g. FreeBSD prior to 13.1
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A temporary directory may be a symlink, e.g. /var to /private/var.
+	want, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != want {
+		t.Errorf("want %s, got %s", want, s)
+	}
+}
+
+func TestFile_renameChain(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	f, err := open(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rotate.Wrap(f, rotate.Config{Bytes: 1, Count: 3})
+	if err == rotate.ErrNotSupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, s := range []string{"1", "2", "3", "4"} {
+		write(t, r, s)
+	}
+	for name, want := range map[string]string{"a.2": "2", "a.1": "3", "a": "4"} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+	notExist(t, root, "a.3")
+}
@@ -0,0 +1,27 @@
+// +build darwin
+
+package rotate
+
+import (
+	"bytes"
+	"path"
+	"syscall"
+	"unsafe"
+)
+
+// maxPathLen is MAXPATHLEN, a size of a buffer of F_GETPATH.
+const maxPathLen = 1024
+
+// Dirname returns a directory containing fd.
+func Dirname(fd uintptr) (string, error) {
+	var buf [maxPathLen]byte
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, syscall.F_GETPATH, uintptr(unsafe.Pointer(&buf[0])))
+	if errno != 0 {
+		return "", errno
+	}
+	n := bytes.IndexByte(buf[:], 0)
+	if n < 0 {
+		n = len(buf)
+	}
+	return path.Dir(string(buf[:n])), nil
+}
@@ -0,0 +1,43 @@
+// +build freebsd
+
+package rotate
+
+import (
+	"bytes"
+	"path"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// fKinfo is F_KINFO, which fills struct kinfo_file of a descriptor.
+	// It is supported since FreeBSD 13.1.
+	fKinfo = 22
+	// kinfoFileSize is KINFO_FILE_SIZE, a size of struct kinfo_file.
+	kinfoFileSize = 1392
+	// kinfoPathOff is an offset of kf_path, the last field of
+	// struct kinfo_file of PATH_MAX bytes.
+	kinfoPathOff = kinfoFileSize - 1024
+)
+
+// Dirname returns a directory containing fd. ErrNotSupported is returned
+// on FreeBSD prior to 13.1 and for files without a known path.
+func Dirname(fd uintptr) (string, error) {
+	var buf [kinfoFileSize]byte
+	*(*int32)(unsafe.Pointer(&buf[0])) = kinfoFileSize // kf_structsize
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, fKinfo, uintptr(unsafe.Pointer(&buf[0])))
+	if errno == syscall.EINVAL {
+		return "", ErrNotSupported
+	}
+	if errno != 0 {
+		return "", errno
+	}
+	s := buf[kinfoPathOff:]
+	if n := bytes.IndexByte(s, 0); n >= 0 {
+		s = s[:n]
+	}
+	if len(s) == 0 {
+		return "", ErrNotSupported
+	}
+	return path.Dir(string(s)), nil
+}
@@ -3,8 +3,23 @@
 package rotate_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/koorgoo/rotate"
 )
@@ -99,3 +114,1973 @@ func TestFile_doesNotRenameAllFilesOnError(t *testing.T) {
 		t.Fatal("a.2 was not renamed to a.3")
 	}
 }
+
+func TestFile_defersRotationInBlackout(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes:    1,
+		Count:    2,
+		Blackout: []rotate.Window{{From: 0, To: 24 * time.Hour}},
+	})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "1")
+
+	notExist(t, root, "a.1")
+}
+
+func TestFile_limitsRotations(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var events []rotate.Event
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes:        1,
+		Count:        3,
+		MaxRotations: 1,
+		OnEvent:      func(e rotate.Event) { events = append(events, e) },
+	})
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "1")
+	exist(t, root, "a.1")
+
+	// limited
+	write(t, r, "1")
+	write(t, r, "1")
+	notExist(t, root, "a.2")
+
+	if len(events) != 1 || events[0].Type != rotate.RotationLimited {
+		t.Fatalf("want a single RotationLimited event, got %v", events)
+	}
+}
+
+func TestFile_callsOnWrite(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var calls [][2]int64
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes: 2,
+		Count: 2,
+		OnWrite: func(n int, total int64) {
+			calls = append(calls, [2]int64{int64(n), total})
+		},
+	})
+	defer r.Close()
+
+	write(t, r, "12")
+	write(t, r, "345") // rotation does not reset total
+
+	want := [][2]int64{{2, 2}, {3, 5}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("want %v, got %v", want, calls)
+	}
+}
+
+// inode returns inode number of file.
+// It calls t.Fatal() on error.
+func inode(t *testing.T, root, name string) uint64 {
+	v, err := stat(root, name)
+	if err != nil {
+		t.Fatalf("inode: %v", err)
+	}
+	s := v.Sys().(*syscall.Stat_t)
+	return s.Ino
+}
+
+func TestFile_detectsTruncation(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 2, Count: 2})
+	defer r.Close()
+
+	write(t, r, "12")
+	if err := os.Truncate(filepath.Join(root, "a"), 0); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "1")
+
+	notExist(t, root, "a.1")
+}
+
+func TestFile_keepsModeOnRotation(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	if err := os.Chmod(filepath.Join(root, "a"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 2})
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "1")
+
+	v, err := stat(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Mode().Perm() != 0666 {
+		t.Fatalf("want mode %v, got %v", os.FileMode(0666), v.Mode().Perm())
+	}
+}
+
+func TestFile_copyTruncateKeepsInode(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 2, Method: rotate.CopyTruncate})
+	defer r.Close()
+
+	i1 := inode(t, root, "a")
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "2")
+
+	if i2 := inode(t, root, "a"); i1 != i2 {
+		t.Fatal("a must keep inode")
+	}
+	b, err := ioutil.ReadFile(filepath.Join(root, "a.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "1" {
+		t.Fatalf("a.1: want %q, got %q", "1", b)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "2" {
+		t.Fatalf("a: want %q, got %q", "2", b)
+	}
+}
+
+type syncFailFile struct{ *os.File }
+
+func (f syncFailFile) Sync() error { return errors.New("sync failed") }
+
+func TestFile_syncOnRotateKeepsFileOnError(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	f, err := open(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rotate.Wrap(syncFailFile{f}, rotate.Config{Bytes: 1, Count: 2, SyncOnRotate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "1")
+
+	notExist(t, root, "a.1")
+}
+
+func TestFile_callsOnRotate(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var infos []rotate.RotationInfo
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes:    2,
+		Count:    2,
+		OnRotate: func(info rotate.RotationInfo) { infos = append(infos, info) },
+	})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "2")
+	// trigger rotation
+	write(t, r, "3")
+
+	if len(infos) != 1 {
+		t.Fatalf("want 1 rotation, got %d", len(infos))
+	}
+	info := infos[0]
+	if info.Filename != filepath.Join(root, "a.1") {
+		t.Errorf("want %q, got %q", filepath.Join(root, "a.1"), info.Filename)
+	}
+	if info.Size != 2 {
+		t.Errorf("want 2 bytes, got %d", info.Size)
+	}
+	if info.First.IsZero() || info.Last.Before(info.First) {
+		t.Errorf("invalid time range: %v - %v", info.First, info.Last)
+	}
+}
+
+func TestFile_timestampNaming(t *testing.T) {
+	root := touch(t, "a", "a.20181001T120000")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 2, Naming: rotate.Timestamp})
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "1")
+
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 2 || v[1] == "a.20181001T120000" {
+		t.Fatalf("want a and a new timestamped file, got %v", v)
+	}
+}
+
+func TestFile_timestampCollision(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 5, Naming: rotate.Timestamp})
+	defer r.Close()
+
+	// Rotations within a second must not collide.
+	for i := 0; i < 4; i++ {
+		write(t, r, "1")
+	}
+
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 4 {
+		t.Fatalf("want a and 3 timestamped files, got %v", v)
+	}
+}
+
+func TestFile_anchored(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	c := rotate.Config{Count: 2, Interval: time.Hour, Anchored: true}
+	r := ropen(t, root, "a", c)
+	write(t, r, "1")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	exist(t, root, ".a.anchor")
+
+	// The anchor survives reopening.
+	r = ropen(t, root, "a", c)
+	write(t, r, "1")
+	notExist(t, root, "a.1")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-2 * time.Hour).Format(time.RFC3339Nano)
+	if err := ioutil.WriteFile(filepath.Join(root, ".a.anchor"), []byte(past), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r = ropen(t, root, "a", c)
+	defer r.Close()
+	write(t, r, "1")
+	exist(t, root, "a.1")
+
+	// A new file is anchored to its first write.
+	b, err := ioutil.ReadFile(filepath.Join(root, ".a.anchor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) == past {
+		t.Error("want a new anchor")
+	}
+}
+
+func TestFile_useUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	defer func() { time.Local = local }()
+
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 2, Naming: rotate.Timestamp, UseUTC: true})
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "1")
+
+	v, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 2 {
+		t.Fatalf("want a and a timestamped file, got %v", v)
+	}
+	at, err := time.ParseInLocation(rotate.TimestampFormat, v[1][len("a."):], time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(at); d < -time.Minute || d > time.Minute {
+		t.Errorf("want %s in UTC, got %s", time.Now().UTC().Format(rotate.TimestampFormat), v[1])
+	}
+}
+
+func TestFile_interval(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	if err := ioutil.WriteFile(filepath.Join(root, "a"), []byte("1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-25 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a"), yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	r := ropen(t, root, "a", rotate.Config{Count: 2, Interval: 24 * time.Hour})
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	exist(t, root, "a.1")
+
+	// same day
+	write(t, r, "1")
+	notExist(t, root, "a.2")
+	v, err := stat(root, "a.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Size() != 1 {
+		t.Fatalf("a.1 must not be rotated again, got %d bytes", v.Size())
+	}
+}
+
+func TestWrap_probesDirectory(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	f, err := os.Create(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = rotate.Wrap(f, rotate.Config{Bytes: 1})
+	if _, ok := err.(*rotate.Error); !ok {
+		t.Fatalf("want *rotate.Error, got %v", err)
+	}
+}
+
+func TestOpen_flagWithoutCreate(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 2, Flag: os.O_APPEND | os.O_WRONLY})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "2") // rotation creates a new file
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "2" {
+		t.Fatalf("want %q, got %q", "2", b)
+	}
+}
+
+func TestWrap_staleProbe(t *testing.T) {
+	probe := fmt.Sprintf(".a.probe%d", os.Getpid())
+	root := touch(t, "a", probe, probe+".1")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1})
+	defer r.Close()
+
+	notExist(t, root, probe)
+	notExist(t, root, probe+".1")
+}
+
+func TestFile_syncRotated(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var synced []string
+	defer rotate.SetSyncer(func(name string) error {
+		synced = append(synced, name)
+		return nil
+	})()
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3, SyncRotated: true})
+	defer r.Close()
+
+	// trigger rotations
+	write(t, r, "1")
+	write(t, r, "2")
+	write(t, r, "3")
+	if len(synced) != 0 {
+		t.Fatalf("want no syncs before Sync, got %v", synced)
+	}
+
+	if err := r.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	exist(t, root, "a.1")
+	exist(t, root, "a.2")
+	want := []string{filepath.Join(root, "a.1"), filepath.Join(root, "a.2"), root}
+	if !reflect.DeepEqual(synced, want) {
+		t.Fatalf("want %v synced, got %v", want, synced)
+	}
+
+	// Files are synced once.
+	synced = nil
+	if err := r.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if len(synced) != 0 {
+		t.Fatalf("want no syncs, got %v", synced)
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var mu sync.Mutex
+	var synced []string
+	defer rotate.SetSyncer(func(name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		synced = append(synced, name)
+		return nil
+	})()
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3, Multiline: true, SyncRotated: true})
+	defer r.Close()
+	// SIGWINCH is ignored by default, so raising it again does not exit.
+	defer rotate.HandleSignals(r, syscall.SIGWINCH)()
+
+	write(t, r, "1\n")
+	write(t, r, "2\n") // held until Flush
+	notExist(t, root, "a.1")
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGWINCH); err != nil {
+		t.Fatal(err)
+	}
+	// A directory is synced the last.
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		mu.Lock()
+		n := len(synced)
+		done := n > 0 && synced[n-1] == root
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatal("want a directory synced")
+		}
+	}
+
+	// Flush wrote a held record and rotated a file.
+	for name, want := range map[string]string{"a": "2\n", "a.1": "1\n"} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+	want := []string{filepath.Join(root, "a.1"), root}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(synced, want) {
+		t.Fatalf("want %v synced, got %v", want, synced)
+	}
+}
+
+func TestFile_reportsRotationErrors(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		root := touch(t, "a", "a.1", "a.2", "a.3")
+		defer os.RemoveAll(root)
+
+		var events []rotate.Event
+		r := ropen(t, root, "a", rotate.Config{
+			Bytes:          1,
+			Count:          4,
+			RotationErrors: legacy,
+			OnEvent:        func(e rotate.Event) { events = append(events, e) },
+		})
+		defer r.Close()
+
+		remove(t, root, "a.1") // fails rotation
+
+		write(t, r, "1")
+		n, err := r.WriteString("1")
+		if n != 1 {
+			t.Fatalf("want 1 byte, wrote %d bytes", n)
+		}
+		if _, ok := err.(*rotate.Error); ok != legacy {
+			t.Fatalf("legacy=%v: unexpected error: %v", legacy, err)
+		}
+		if len(events) != 1 || events[0].Type != rotate.RotationFailed {
+			t.Fatalf("want %s event, got %v", rotate.RotationFailed, events)
+		}
+		if _, ok := events[0].Err.(*rotate.Error); !ok {
+			t.Fatalf("want *rotate.Error, got %v", events[0].Err)
+		}
+	}
+}
+
+var SplitWritesTests = []struct {
+	Mode  rotate.SplitMode
+	Write string
+	Files []string // from the newest
+}{
+	{rotate.SplitNone, "1234567", []string{"1234567"}},
+	{rotate.SplitBytes, "1234567", []string{"567", "1234"}},
+	{rotate.SplitBytes, "123456789", []string{"9", "5678", "1234"}},
+	{rotate.SplitLines, "1\n2\n3\n4", []string{"3\n4", "1\n2\n"}},
+	{rotate.SplitLines, "12345\n6", []string{"6", "12345\n"}},
+}
+
+func TestFile_splitWrites(t *testing.T) {
+	for _, tt := range SplitWritesTests {
+		root := touch(t, "a")
+		defer os.RemoveAll(root)
+
+		r := ropen(t, root, "a", rotate.Config{Bytes: 4, Count: 3, SplitWrites: tt.Mode})
+		write(t, r, tt.Write)
+		r.Close()
+
+		names, err := rotate.List(root, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, name := range names {
+			b, err := ioutil.ReadFile(filepath.Join(root, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, string(b))
+		}
+		if !reflect.DeepEqual(files, tt.Files) {
+			t.Errorf("%s: want %q, got %q", tt.Mode, tt.Files, files)
+		}
+	}
+}
+
+func TestFile_metadataDuringRotation(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 2})
+	defer r.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = r.Name()
+			_, _ = r.Stat()
+			_ = r.Fd()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		write(t, r, "1")
+	}
+	<-done
+}
+
+func TestFile_jsonLines(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 8, Count: 10, JSONLines: true, SplitWrites: rotate.SplitBytes})
+	for _, s := range []string{`{"a":`, `1}`, "\n", `{"b":2}` + "\n" + `{"c":`, `3}` + "\n" + `{"d":4}`} {
+		write(t, r, s)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := rotate.NewRecords(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer records.Close()
+
+	var got []string
+	for {
+		v, err := records.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(v))
+	}
+	want := []string{`{"a":1}`, `{"b":2}`, `{"c":3}`, `{"d":4}`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	names, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 4 {
+		t.Fatalf("want a record per file, got %v", names)
+	}
+}
+
+func TestFile_csvHeader(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 8, Count: 3, Header: rotate.CSVHeader("k", "v")})
+	write(t, r, "a,1\n")
+	write(t, r, "b,2\n") // rotation
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "k,v\nb,2\n" {
+		t.Fatalf("want a header in a new file, got %q", b)
+	}
+
+	cr, err := rotate.NewCSVReader(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cr.Close()
+
+	var got [][]string
+	for {
+		v, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	want := [][]string{{"k", "v"}, {"a", "1"}, {"b", "2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestFile_sizeFunc(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	// count a write as 10 times larger, as if it was compressed by a caller
+	size := func(b []byte) int64 { return 10 * int64(len(b)) }
+	r := ropen(t, root, "a", rotate.Config{Bytes: 10, Count: 2, SizeFunc: size})
+	defer r.Close()
+
+	write(t, r, "1")
+	notExist(t, root, "a.1")
+
+	// trigger rotation
+	write(t, r, "2")
+	exist(t, root, "a.1")
+}
+
+func TestFile_generation(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var got []int64
+	c := rotate.Config{
+		Bytes:    1,
+		Count:    2,
+		Manifest: true,
+		OnRotate: func(info rotate.RotationInfo) { got = append(got, info.Generation) },
+	}
+
+	r := ropen(t, root, "a", c)
+	write(t, r, "1")
+	write(t, r, "2") // rotation
+	write(t, r, "3") // rotation
+	r.Close()
+
+	// persists across restarts
+	r = ropen(t, root, "a", c)
+	write(t, r, "4") // rotation
+	r.Close()
+
+	want := []int64{1, 2, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	names, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("manifest must not be listed, got %v", names)
+	}
+}
+
+func TestFile_ids(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var infos []rotate.RotationInfo
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes:    1,
+		Count:    3,
+		IDs:      true,
+		Compress: true,
+		OnRotate: func(info rotate.RotationInfo) { infos = append(infos, info) },
+	})
+	defer r.Close()
+
+	id, err := rotate.ID(filepath.Join(root, "a"))
+	if err == rotate.ErrNotSupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(t, r, "1")
+	write(t, r, "2") // rotation
+
+	if err := r.Sync(); err != nil { // waits for compression
+		t.Fatal(err)
+	}
+
+	if len(infos) != 1 || infos[0].ID != id {
+		t.Fatalf("want a rotated file with ID %s, got %v", id, infos)
+	}
+	if v, _ := rotate.ID(filepath.Join(root, "a.1.gz")); v != id {
+		t.Fatalf("want a.1.gz with ID %s, got %q", id, v)
+	}
+	if v, _ := rotate.ID(filepath.Join(root, "a")); v == "" || v == id {
+		t.Fatalf("want a new ID of a, got %q", v)
+	}
+}
+
+func TestOpenRotation(t *testing.T) {
+	for _, manifest := range []bool{false, true} {
+		root := touch(t, "a")
+		defer os.RemoveAll(root)
+
+		r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3, Compress: true, Manifest: manifest})
+		for _, s := range []string{"1", "2", "3", "4"} {
+			write(t, r, s)
+		}
+		r.Close()
+
+		// 1 was removed by Count
+		want := map[int]string{1: "3", 2: "2"}
+		if manifest {
+			want = map[int]string{3: "3", 2: "2"}
+		}
+		for g, s := range want {
+			rc, err := rotate.OpenRotation(root, "a", g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != s {
+				t.Errorf("manifest=%v: generation %d: want %q, got %q", manifest, g, s, b)
+			}
+		}
+		if _, err := rotate.OpenRotation(root, "a", 4); !os.IsNotExist(err) {
+			t.Fatalf("want not exist error, got %v", err)
+		}
+	}
+}
+
+func TestOpenRotation_gap(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 5, Manifest: true})
+	for _, s := range []string{"1", "2", "3", "4"} {
+		write(t, r, s)
+	}
+	// a.1=3 a.2=2 a.3=1, generation 3 is a.1
+	if err := os.Remove(filepath.Join(root, "a.2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rotate.Rescan(r); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "5") // a.1=4 a.2=3 a.4=1
+	r.Close()
+
+	for g, s := range map[int]string{4: "4", 3: "3", 1: "1"} {
+		rc, err := rotate.OpenRotation(root, "a", g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != s {
+			t.Errorf("generation %d: want %q, got %q", g, s, b)
+		}
+	}
+	if _, err := rotate.OpenRotation(root, "a", 2); !os.IsNotExist(err) {
+		t.Fatalf("want not exist error, got %v", err)
+	}
+}
+
+func TestFile_group(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	group := "12345" // root may chgrp to any group
+	if os.Geteuid() != 0 {
+		groups, err := os.Getgroups()
+		if err != nil || len(groups) == 0 {
+			t.Skip("no supplementary groups")
+		}
+		group = fmt.Sprint(groups[len(groups)-1])
+	}
+
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Bytes: 1, Count: 2, Group: group})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "2") // rotation
+
+	for _, name := range []string{"a", "a.1"} {
+		v, err := stat(root, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := fmt.Sprint(v.Sys().(*syscall.Stat_t).Gid); s != group {
+			t.Errorf("%s: want group %s, got %s", name, group, s)
+		}
+	}
+}
+
+func TestFile_copyACL(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	// user::rw- user:1000:r-- group::r-- mask::r-- other::r--
+	acl := []byte{
+		2, 0, 0, 0,
+		1, 0, 6, 0, 0xff, 0xff, 0xff, 0xff,
+		2, 0, 4, 0, 0xe8, 0x03, 0, 0,
+		4, 0, 4, 0, 0xff, 0xff, 0xff, 0xff,
+		0x10, 0, 4, 0, 0xff, 0xff, 0xff, 0xff,
+		0x20, 0, 4, 0, 0xff, 0xff, 0xff, 0xff,
+	}
+	name := filepath.Join(root, "a")
+	if err := syscall.Setxattr(name, "system.posix_acl_access", acl, 0); err != nil {
+		t.Skip("no ACL support:", err)
+	}
+
+	r, err := rotate.Open(name, rotate.Config{Bytes: 1, Count: 2, CopyACL: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "2") // rotation
+
+	b := make([]byte, 64)
+	n, err := syscall.Getxattr(name, "system.posix_acl_access", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b[:n], acl) {
+		t.Errorf("want ACL %v, got %v", acl, b[:n])
+	}
+}
+
+func TestFile_protect(t *testing.T) {
+	for _, mode := range []rotate.ProtectMode{rotate.ProtectReadOnly, rotate.ProtectImmutable} {
+		t.Run(mode.String(), func(t *testing.T) {
+			root := touch(t, "a")
+			defer os.RemoveAll(root)
+			if mode == rotate.ProtectImmutable {
+				if _, err := exec.LookPath("chattr"); err != nil {
+					t.Skip("no chattr to clean up")
+				}
+				defer exec.Command("chattr", "-R", "-i", root).Run()
+			}
+
+			var events []rotate.Event
+			r := ropen(t, root, "a", rotate.Config{
+				Bytes:   2,
+				Count:   3,
+				Protect: mode,
+				OnEvent: func(e rotate.Event) { events = append(events, e) },
+			})
+			defer r.Close()
+
+			for i := 0; i < 4; i++ {
+				write(t, r, "12")
+			}
+			if len(events) > 0 {
+				if mode == rotate.ProtectImmutable {
+					t.Skip("no CAP_LINUX_IMMUTABLE:", events[0])
+				}
+				t.Fatalf("want no events, got %v", events)
+			}
+
+			for _, name := range []string{"a.1", "a.2"} {
+				v, err := stat(root, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if v.Mode()&0222 != 0 {
+					t.Errorf("%s: want read-only, got %s", name, v.Mode())
+				}
+			}
+			err := os.Remove(filepath.Join(root, "a.1"))
+			if mode == rotate.ProtectImmutable && err == nil {
+				t.Fatal("want a.1 not to be removed")
+			}
+		})
+	}
+}
+
+func TestScheduleRotation(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Count: 3})
+	write(t, r, "1")
+
+	v := <-rotate.ScheduleRotation(r, time.Now().Add(10*time.Millisecond))
+	if v.Err != nil {
+		t.Fatal(v.Err)
+	}
+	if want := filepath.Join(root, "a.1"); v.Info.Filename != want || v.Info.Size != 1 {
+		t.Fatalf("want %s of 1 byte, got %+v", want, v.Info)
+	}
+	write(t, r, "2")
+
+	ch := rotate.ScheduleRotation(r, time.Now().Add(time.Hour))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-ch; v.Err != os.ErrClosed {
+		t.Fatalf("want %v, got %v", os.ErrClosed, v.Err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("want channel closed")
+	}
+
+	for name, want := range map[string]string{"a": "2", "a.1": "1"} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+}
+
+func TestRotor_Rotate(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	write(t, r, "1")
+
+	info, err := r.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "a.1"); info.Filename != want {
+		t.Fatalf("want %s, got %s", want, info.Filename)
+	}
+	if _, _, ok := r.NextRotation(); ok {
+		t.Error("want no rotation without Bytes and Interval")
+	}
+}
+
+var PassthroughTests = []struct {
+	Name   string
+	Config rotate.Config
+	Header string
+}{
+	{"rename", rotate.Config{Count: 2}, ""},
+	{"copytruncate", rotate.Config{Count: 2, Method: rotate.CopyTruncate}, ""},
+	{"header", rotate.Config{Count: 2, Header: func(w io.Writer) error {
+		_, err := io.WriteString(w, "h")
+		return err
+	}}, "h"},
+}
+
+func TestRotor_Rotate_passthrough(t *testing.T) {
+	for _, tt := range PassthroughTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			root := touch(t)
+			defer os.RemoveAll(root)
+
+			r, err := rotate.Open(filepath.Join(root, "a"), tt.Config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			const writers, writes = 4, 100
+			var wg sync.WaitGroup
+			for i := 0; i < writers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < writes; j++ {
+						if _, err := r.WriteString("1"); err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			info, err := r.Rotate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wg.Wait()
+
+			a, err := ioutil.ReadFile(filepath.Join(root, "a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			a1, err := ioutil.ReadFile(filepath.Join(root, "a.1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, b := range map[string][]byte{"a": a, "a.1": a1} {
+				if !bytes.HasPrefix(b, []byte(tt.Header)) {
+					t.Errorf("%s: want header %q, got %q", name, tt.Header, b)
+				}
+			}
+			if n := bytes.Count(a, []byte("1")) + bytes.Count(a1, []byte("1")); n != writers*writes {
+				t.Errorf("want %d bytes written, got %d", writers*writes, n)
+			}
+			// Writes racing with rotation may still reach a sealed file.
+			if info.Size > int64(len(a1)) {
+				t.Errorf("want size at most %d, got %d", len(a1), info.Size)
+			}
+		})
+	}
+}
+
+func TestRotor_Rotate_passthroughStress(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	// A manifest and a long chain make finish slow.
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Count: 50, Manifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	const writers, rotations = 8, 100
+	var failed int64
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := r.WriteString("1"); err != nil {
+					atomic.AddInt64(&failed, 1)
+				}
+			}
+		}()
+	}
+	for i := 0; i < rotations; i++ {
+		if _, err := r.Rotate(); err != nil {
+			t.Error(err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+	if failed > 0 {
+		t.Fatalf("want no failed writes, got %d", failed)
+	}
+}
+
+var WriteBatchTests = []struct {
+	Name   string
+	Config rotate.Config
+	A1, A  string
+}{
+	{"plain", rotate.Config{Bytes: 3, Count: 3, SplitWrites: rotate.SplitLines}, "1\n2\n", "3\n"},
+	{"stamp", rotate.Config{Bytes: 3, Count: 3, Stamp: "x"}, "x 2\n", "x 3\n"},
+}
+
+func TestRotor_WriteBatch(t *testing.T) {
+	for _, tt := range WriteBatchTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			root := touch(t)
+			defer os.RemoveAll(root)
+
+			r, err := rotate.Open(filepath.Join(root, "a"), tt.Config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			batches := [][][]byte{
+				{[]byte("1\n"), []byte("2\n")},
+				{[]byte("3\n")},
+			}
+			for _, lines := range batches {
+				n, err := r.WriteBatch(lines)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := len(bytes.Join(lines, nil)); n != want {
+					t.Fatalf("want %d, got %d", want, n)
+				}
+			}
+			for name, want := range map[string]string{"a.1": tt.A1, "a": tt.A} {
+				b, err := ioutil.ReadFile(filepath.Join(root, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != want {
+					t.Errorf("%s: want %q, got %q", name, want, b)
+				}
+			}
+		})
+	}
+}
+
+func TestBufferedFile(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Bytes: 1, Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := rotate.NewBufferedFile(r, 0)
+	defer b.Close()
+
+	write(t, b, "1")
+	write(t, b, "2")
+	if n := b.Buffered(); n != 2 {
+		t.Fatalf("want 2 bytes buffered, got %d", n)
+	}
+	notExist(t, root, "a.1")
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, b, "3")
+	if err := b.Flush(); err != nil { // rotation
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"a.1": "12", "a": "3"} {
+		v, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != want {
+			t.Errorf("%s: want %q, got %q", name, want, v)
+		}
+	}
+}
+
+func TestFile_linkDuplicates(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Bytes: 1, Count: 4, LinkDuplicates: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, s := range []string{"x", "x", "x", "y", "z"} {
+		write(t, r, s)
+	}
+
+	v1, err := stat(root, "a.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := stat(root, "a.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v3, err := stat(root, "a.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(v2, v3) {
+		t.Error("want a.2 linked to a.3")
+	}
+	if os.SameFile(v1, v2) {
+		t.Error("want a.1 not linked")
+	}
+}
+
+func TestFile_linkDuplicatesFailure(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	// A directory at a temporary name fails a link.
+	tmp := filepath.Join(root, "a.1.tmp")
+	if err := os.Mkdir(tmp, 0755); err != nil {
+		t.Fatal(err)
+	}
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Bytes: 1, Count: 4, LinkDuplicates: true, RotationErrors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	write(t, r, "x")
+	write(t, r, "x")
+	if _, err := r.WriteString("x"); err == nil {
+		t.Fatal("want link error")
+	}
+	for _, name := range []string{"a.1", "a.2"} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "x" {
+			t.Errorf("%s: want %q, got %q", name, "x", b)
+		}
+	}
+
+	// A chain is not broken.
+	if err := os.Remove(tmp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.WriteString("y"); err != nil {
+		t.Fatal(err)
+	}
+	exist(t, root, "a.3")
+}
+
+func TestRotor_Stats(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Bytes: 1, Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for i := 0; i < 3; i++ {
+		write(t, r, "1")
+	}
+
+	v := r.Stats()
+	if v.Rotations != 2 {
+		t.Fatalf("want 2 rotations, got %d", v.Rotations)
+	}
+	var n int64
+	for _, c := range v.Latency.Counts {
+		n += c
+	}
+	if n != 2 || v.Latency.Sum <= 0 {
+		t.Errorf("want 2 durations in histogram, got %+v", v.Latency)
+	}
+}
+
+// slowTruncate is a file truncated slowly, like on a hung file system.
+type slowTruncate struct{ *os.File }
+
+func (f slowTruncate) Truncate(size int64) error {
+	time.Sleep(100 * time.Millisecond)
+	return f.File.Truncate(size)
+}
+
+func TestFile_slowRotation(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+	f, err := os.OpenFile(filepath.Join(root, "a"), rotate.OpenFlag, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan rotate.Event, 1)
+	r, err := rotate.Wrap(slowTruncate{f}, rotate.Config{
+		Bytes:        1,
+		Count:        2,
+		Method:       rotate.CopyTruncate,
+		SlowRotation: 10 * time.Millisecond,
+		OnEvent: func(e rotate.Event) {
+			if e.Type == rotate.RotationSlow {
+				events <- e
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	write(t, r, "1")
+	write(t, r, "1")
+
+	select {
+	case e := <-events:
+		if e.Stage != "truncate" {
+			t.Errorf("want truncate stage, got %q", e.Stage)
+		}
+	default:
+		t.Fatal("want RotationSlow emitted")
+	}
+}
+
+func TestFile_renameTimeout(t *testing.T) {
+	root := touch(t, "a", "a.1")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3, RenameTimeout: time.Nanosecond, RotationErrors: true})
+	defer r.Close()
+	write(t, r, "1")
+
+	_, err := r.Write([]byte("2"))
+	if e, ok := err.(*rotate.Error); !ok || e.Err != rotate.ErrRenameTimeout {
+		t.Fatalf("want %v, got %v", rotate.ErrRenameTimeout, err)
+	}
+	// The first rename is done, the others are skipped, so writes continue
+	// to a current file and a chain has a gap.
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "12" {
+		t.Errorf("want %q, got %q", "12", b)
+	}
+	notExist(t, root, "a.1")
+	exist(t, root, "a.2")
+
+	// A next rotation compacts the chain and removes no file.
+	if _, err := r.Write([]byte("3")); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"a": "3", "a.1": "12", "a.2": ""} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+}
+
+func TestFile_promoteTmpfile(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	// A directory at a temporary name fails a fallback, so rotation
+	// succeeds only with a file created by O_TMPFILE.
+	if err := os.Mkdir(filepath.Join(root, "a.tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{
+		Bytes:          1,
+		Count:          2,
+		Promote:        true,
+		RotationErrors: true,
+		Header:         rotate.CSVHeader("x"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	write(t, r, "1")
+	write(t, r, "2") // rotation
+
+	// Without a new file, writes would continue to a.1.
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatalf("want a file created with O_TMPFILE: %v", err)
+	}
+	if string(b) != "x\n2" {
+		t.Errorf("want %q, got %q", "x\n2", b)
+	}
+}
+
+func TestRotor_Detach(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+	name := filepath.Join(root, "a")
+
+	c := rotate.Config{Bytes: 2, Count: 3}
+	r, err := rotate.Open(name, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := rotate.Open(name, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Detach(); err != rotate.ErrShared {
+		t.Fatalf("want %v, got %v", rotate.ErrShared, err)
+	}
+	if err := r2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "1")
+
+	f, err := r.Detach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Rotation is abandoned.
+	if _, err := f.WriteString("23"); err != nil {
+		t.Fatal(err)
+	}
+	notExist(t, root, "a.1")
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "123" {
+		t.Errorf("want %q, got %q", "123", b)
+	}
+	if _, err := r.Detach(); err != os.ErrClosed {
+		t.Errorf("want %v, got %v", os.ErrClosed, err)
+	}
+}
+
+var CaptureTests = []struct {
+	Stderr string
+	Want   map[string]string
+}{
+	{"", map[string]string{"a": "out\nerr\n"}},
+	{"b", map[string]string{"a": "out\n", "b": "err\n"}},
+}
+
+func TestCapture(t *testing.T) {
+	for _, tt := range CaptureTests {
+		root := touch(t)
+		defer os.RemoveAll(root)
+
+		stderr := tt.Stderr
+		if stderr != "" {
+			stderr = filepath.Join(root, stderr)
+		}
+		cmd := exec.Command("sh", "-c", "echo out; echo err >&2")
+		wait, err := rotate.Capture(cmd, filepath.Join(root, "a"), stderr, rotate.Config{Count: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		if err := wait(); err != nil {
+			t.Fatal(err)
+		}
+		for name, want := range tt.Want {
+			b, err := ioutil.ReadFile(filepath.Join(root, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != want {
+				t.Errorf("%s: want %q, got %q", name, want, b)
+			}
+		}
+	}
+}
+
+func TestOpenContext_hung(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	// An open of a FIFO for writing blocks until it is opened for reading.
+	fifo := filepath.Join(root, "a")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := rotate.OpenContext(ctx, fifo, rotate.Config{}); err != context.DeadlineExceeded {
+		t.Fatalf("want %v, got %v", context.DeadlineExceeded, err)
+	}
+	defer func() {
+		p, err := os.Open(fifo) // unblocks the open
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Close()
+	}()
+
+	// The hung open blocks no other file.
+	ch := make(chan error, 1)
+	go func() {
+		f, err := rotate.Open(filepath.Join(root, "b"), rotate.Config{})
+		if f != nil {
+			f.Close()
+		}
+		ch <- err
+	}()
+	select {
+	case err := <-ch:
+		if err != nil && err != rotate.ErrNotSupported {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("open is blocked")
+	}
+}
+
+func TestServePipe(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+	name := filepath.Join(root, "pipe")
+
+	s, err := rotate.ServePipe(name, filepath.Join(root, "a"), rotate.Config{Bytes: 2, Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Writers may come and go.
+	for _, line := range []string{"1\n", "2\n"} {
+		w, err := os.OpenFile(name, os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	notExist(t, root, "pipe")
+
+	for name, want := range map[string]string{"a": "2\n", "a.1": "1\n"} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+}
+
+func TestServePipe_notPipe(t *testing.T) {
+	root := touch(t, "pipe")
+	defer os.RemoveAll(root)
+
+	s, err := rotate.ServePipe(filepath.Join(root, "pipe"), filepath.Join(root, "a"), rotate.Config{})
+	if err != rotate.ErrNotPipe {
+		t.Fatalf("want %v, got %v", rotate.ErrNotPipe, err)
+	}
+	if s != nil {
+		t.Fatal("want nil server")
+	}
+	exist(t, root, "pipe")
+	notExist(t, root, "a")
+}
+
+func TestPool_SetIdleTimeout(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+	name := filepath.Join(root, "a")
+
+	p := rotate.NewPool(rotate.Config{Count: 2})
+	defer p.Close()
+	p.SetIdleTimeout(20 * time.Millisecond)
+
+	f, err := p.Get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, f, "1")
+	time.Sleep(100 * time.Millisecond)
+
+	// A file closed as idle is reopened by name.
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	write(t, f, "2")
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "2" {
+		t.Errorf("want %q, got %q", "2", b)
+	}
+}
+
+func TestPool_packageFunctions(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	p := rotate.NewPool(rotate.Config{Count: 2})
+	defer p.Close()
+	p.SetIdleTimeout(20 * time.Millisecond)
+
+	f, err := p.Get(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, f, "1")
+	time.Sleep(100 * time.Millisecond) // closed as idle
+
+	if err := rotate.Rescan(f); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := rotate.NextRotation(f); ok {
+		t.Error("want no rotation without Bytes and Interval")
+	}
+	if err := rotate.WithFrozenFile(f, func(string) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-rotate.ScheduleRotation(f, time.Now()); v.Err != nil {
+		t.Fatal(v.Err)
+	}
+	exist(t, root, "a.1")
+}
+
+func TestSetPoolMaxOpen(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	var evicted []string
+	p := rotate.NewPool(rotate.Config{Count: 2, OnEvent: func(e rotate.Event) {
+		if e.Type == rotate.FileEvicted {
+			evicted = append(evicted, filepath.Base(e.Filename))
+		}
+	}})
+	defer p.Close()
+	rotate.SetPoolMaxOpen(2)
+	defer rotate.SetPoolMaxOpen(0)
+	n := rotate.Evictions()
+
+	var files []rotate.File
+	for _, name := range []string{"a", "b"} {
+		f, err := p.Get(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	write(t, files[0], "1") // b is the least recently used
+	if _, err := p.Get(filepath.Join(root, "c")); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(evicted, want) {
+		t.Fatalf("want %v evicted, got %v", want, evicted)
+	}
+	if v := rotate.Evictions() - n; v != 1 {
+		t.Errorf("want 1 eviction, got %d", v)
+	}
+
+	// An evicted file is reopened.
+	write(t, files[1], "2")
+	b, err := ioutil.ReadFile(filepath.Join(root, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "2" {
+		t.Errorf("want %q, got %q", "2", b)
+	}
+}
+
+func TestFile_cleanOrphans(t *testing.T) {
+	root := touch(t, "a", "a.1.gz", "a.2", "a.3.gz.tmp", "a.tmp", ".a.manifest.tmp", "b.1.gz.tmp",
+		".a.probe1", ".a.probe2.1", ".a.probe3")
+	defer os.RemoveAll(root)
+	for _, name := range []string{"a.1", "a.2.gz"} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte("1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{".a.probe1", ".a.probe2.1"} {
+		if err := os.Chtimes(filepath.Join(root, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var removed []string
+	r := ropen(t, root, "a", rotate.Config{Count: 4, CleanOrphans: true, OnEvent: func(e rotate.Event) {
+		if e.Type == rotate.OrphanRemoved && e.Err == nil {
+			removed = append(removed, filepath.Base(e.Filename))
+		}
+	}})
+	defer r.Close()
+
+	sort.Strings(removed)
+	want := []string{".a.manifest.tmp", ".a.probe1", ".a.probe2.1", "a.1.gz", "a.2", "a.3.gz.tmp", "a.tmp"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Fatalf("want %v removed, got %v", want, removed)
+	}
+	for _, name := range []string{"a.1", "a.2.gz", "b.1.gz.tmp", ".a.probe3"} {
+		exist(t, root, name)
+	}
+}
+
+func TestNextRotation(t *testing.T) {
+	root := touch(t, "a", "b", "c", "d")
+	defer os.RemoveAll(root)
+
+	// midnight returns the next midnight after t in UTC, so that the test
+	// does not depend on daylight saving time.
+	midnight := func(t time.Time) time.Time {
+		y, m, d := t.UTC().Date()
+		return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		c      rotate.Config
+		reason string
+		ok     bool
+	}{
+		{"a", rotate.Config{}, "", false},
+		{"b", rotate.Config{Bytes: 1 << 30}, "size", true},
+		{"c", rotate.Config{Bytes: 1}, "size", true},
+		{"d", rotate.Config{Interval: 24 * time.Hour, UseUTC: true}, "interval", true},
+	} {
+		begin := time.Now()
+		r := ropen(t, root, tt.name, tt.c)
+		defer r.Close()
+		write(t, r, "12")
+		time.Sleep(time.Millisecond)
+		write(t, r, "12")
+
+		start := time.Now()
+		v, reason, ok := rotate.NextRotation(r)
+		if reason != tt.reason || ok != tt.ok {
+			t.Errorf("%s: want %q %t, got %q %t", tt.name, tt.reason, tt.ok, reason, ok)
+			continue
+		}
+		switch tt.name {
+		case "b":
+			if !v.After(start.Add(time.Hour)) {
+				t.Errorf("%s: want in more than an hour, got %s", tt.name, v)
+			}
+		case "c":
+			if v.After(time.Now()) {
+				t.Errorf("%s: want now, got %s", tt.name, v)
+			}
+		case "d":
+			// Midnight may pass while the test runs.
+			if !v.Equal(midnight(begin)) && !v.Equal(midnight(start)) {
+				t.Errorf("%s: want %s, got %s", tt.name, midnight(start), v)
+			}
+		}
+	}
+}
+
+func TestWithFrozenFile(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1, Count: 3})
+	defer r.Close()
+	write(t, r, "1")
+
+	var ch <-chan rotate.RotationResult
+	err := rotate.WithFrozenFile(r, func(path string) error {
+		if want := filepath.Join(root, "a"); path != want {
+			t.Errorf("want %s, got %s", want, path)
+		}
+		write(t, r, "2")
+		ch = rotate.ScheduleRotation(r, time.Now())
+		time.Sleep(10 * time.Millisecond)
+		notExist(t, root, "a.1")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := <-ch; v.Err != nil || v.Info.Size != 2 {
+		t.Fatalf("want 2 bytes rotated, got %+v", v)
+	}
+	exist(t, root, "a.1")
+}
+
+func TestFile_hash(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var infos []rotate.RotationInfo
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes:    2,
+		Count:    3,
+		Hash:     sha256.New,
+		OnRotate: func(v rotate.RotationInfo) { infos = append(infos, v) },
+	})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "2")
+	write(t, r, "34") // rotation
+
+	if len(infos) != 1 {
+		t.Fatalf("want 1 rotation, got %d", len(infos))
+	}
+	if want := sha256.Sum256([]byte("12")); !bytes.Equal(infos[0].Hash, want[:]) {
+		t.Fatalf("want %x, got %x", want, infos[0].Hash)
+	}
+}
+
+func TestFile_multiline(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 20, Count: 10, Multiline: true, SplitWrites: rotate.SplitBytes})
+	for _, s := range []string{"panic: x\n", "\tgoroutine 1\n\tmain.go:1\n", "next\n", "  more\n"} {
+		write(t, r, s)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := rotate.NewGroups(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer groups.Close()
+
+	var got []string
+	for {
+		v, err := groups.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(v))
+	}
+	want := []string{"panic: x\n\tgoroutine 1\n\tmain.go:1\n", "next\n  more\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	names, err := rotate.List(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("want a record per file, got %v", names)
+	}
+}
+
+func TestFile_multilineSync(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Multiline: true})
+	defer r.Close()
+	write(t, r, "panic: x\n\tmain.go:1\n")
+	if err := r.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "panic: x\n\tmain.go:1\n"; string(b) != want {
+		t.Errorf("want %q, got %q", want, b)
+	}
+}
+
+func TestWrap_headerError(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	f, err := open(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rotate.Wrap(f, rotate.Config{Header: func(w io.Writer) error {
+		return errors.New("header failed")
+	}})
+	if err == nil {
+		t.Fatal("want header error")
+	}
+	if _, err := f.Write([]byte("1")); err == nil {
+		t.Fatal("want a file closed")
+	}
+}
+
+func TestFile_promote(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	var calls int
+	r := ropen(t, root, "a", rotate.Config{
+		Bytes:   1,
+		Count:   3,
+		Promote: true,
+		Header: func(w io.Writer) error {
+			if calls++; calls == 4 {
+				return errors.New("header failed")
+			}
+			_, err := io.WriteString(w, "h\n")
+			return err
+		},
+	})
+	defer r.Close()
+
+	write(t, r, "1")
+	write(t, r, "2") // rotation
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "h\n2"; string(b) != want {
+		t.Fatalf("want %q, got %q", want, b)
+	}
+
+	write(t, r, "3") // rotation with a failed header
+	notExist(t, root, "a")
+	notExist(t, root, "a.tmp")
+}
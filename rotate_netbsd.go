@@ -0,0 +1,41 @@
+// +build netbsd
+
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// fGetpath is F_GETPATH, which is supported since NetBSD 10.
+	fGetpath = 15
+	// maxPathLen is MAXPATHLEN, a size of a buffer of F_GETPATH.
+	maxPathLen = 1024
+)
+
+// Dirname returns a directory containing fd. It reads a link of fd in
+// procfs if it is mounted and uses F_GETPATH otherwise. ErrNotSupported is
+// returned if neither works.
+func Dirname(fd uintptr) (string, error) {
+	if s, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", fd)); err == nil && path.IsAbs(s) {
+		return path.Dir(s), nil
+	}
+	var buf [maxPathLen]byte
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, fGetpath, uintptr(unsafe.Pointer(&buf[0])))
+	if errno == syscall.EINVAL {
+		return "", ErrNotSupported
+	}
+	if errno != 0 {
+		return "", errno
+	}
+	n := bytes.IndexByte(buf[:], 0)
+	if n < 0 {
+		n = len(buf)
+	}
+	return path.Dir(string(buf[:n])), nil
+}
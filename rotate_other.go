@@ -1,4 +1,4 @@
-// +build !linux
+// +build !linux,!windows,!darwin,!freebsd,!netbsd
 
 package rotate
 
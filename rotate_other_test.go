@@ -1,4 +1,4 @@
-// +build !linux
+// +build !linux,!windows,!darwin,!freebsd,!netbsd
 
 package rotate_test
 
@@ -16,7 +16,11 @@ func TestFile(t *testing.T) {
 	if err != rotate.ErrNotSupported {
 		t.Errorf("want ErrNotSupported, got %v", err)
 	}
-	if r != nil {
-		r.Close()
+	if r == nil {
+		return
+	}
+	defer r.Close()
+	if _, err := r.Rotate(); err != rotate.ErrNotSupported {
+		t.Errorf("Rotate: want ErrNotSupported, got %v", err)
 	}
 }
@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/koorgoo/rotate"
 )
@@ -20,6 +22,8 @@ var SplitTests = []struct {
 	{"a", "a", 0},
 	{"a.1", "a", 1},
 	{"a.99", "a", 99},
+	{"a.10", "a", 10},
+	{"a.1.gz", "a", 1},
 	{"a.0", "a.0", 0},
 	{"a.b", "a.b", 0},
 }
@@ -64,6 +68,11 @@ var ListTests = []ListTest{
 		[]string{"a", "b", "b.1", "c.1", "a.1"},
 		[]string{"a", "a.1"},
 	},
+	{
+		"a",
+		[]string{"a.10", "a.2.gz", "a.1", "a.gz"},
+		[]string{"a", "a.1", "a.2.gz", "a.10"}, // by counter
+	},
 }
 
 func TestList(t *testing.T) {
@@ -86,6 +95,41 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestListInfo(t *testing.T) {
+	root := touch(t, "a", "a.1", "a.2.gz")
+	defer os.RemoveAll(root)
+
+	for _, tt := range []struct {
+		opt  rotate.ListOptions
+		want string
+	}{
+		{rotate.ListOptions{}, "a a.1 a.2.gz(gz)"},
+		{rotate.ListOptions{Offset: 1}, "a.1 a.2.gz(gz)"},
+		{rotate.ListOptions{Limit: 2}, "a a.1"},
+		{rotate.ListOptions{Offset: 1, Limit: 1}, "a.1"},
+		{rotate.ListOptions{Offset: 3}, ""},
+	} {
+		files, err := rotate.ListInfo(root, "a", tt.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, v := range files {
+			if v.ModTime.IsZero() {
+				t.Errorf("%s: no modification time", v.Name)
+			}
+			s := v.Name
+			if v.Compressed {
+				s += "(gz)"
+			}
+			got = append(got, s)
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%+v: want %q, got %q", tt.opt, tt.want, s)
+		}
+	}
+}
+
 // touch creates files with names and returns a root directory.
 func touch(t *testing.T, names ...string) (root string) {
 	root, err := ioutil.TempDir("", "")
@@ -118,17 +162,6 @@ func exist(t *testing.T, root, name string) {
 	}
 }
 
-// inode returns inode number of file.
-// It calls t.Fatal() on error.
-func inode(t *testing.T, root, name string) uint64 {
-	v, err := stat(root, name)
-	if err != nil {
-		t.Fatalf("inode: %v", err)
-	}
-	s := v.Sys().(*syscall.Stat_t)
-	return s.Ino
-}
-
 func ropen(t *testing.T, root, name string, c rotate.Config) (f rotate.File) {
 	f, err := open(root, name)
 	if err != nil {
@@ -166,3 +199,142 @@ func open(root, name string) (*os.File, error) {
 func stat(root, name string) (os.FileInfo, error) {
 	return os.Stat(filepath.Join(root, name))
 }
+
+// interrupted is a file which writes a byte at a time, failing with EINTR.
+type interrupted struct {
+	*os.File
+	root string
+}
+
+func (f *interrupted) Dirname() string { return f.root }
+
+func (f *interrupted) Write(b []byte) (int, error) {
+	if len(b) > 1 {
+		n, _ := f.File.Write(b[:1])
+		return n, &os.PathError{Op: "write", Path: f.Name(), Err: syscall.EINTR}
+	}
+	return f.File.Write(b)
+}
+
+func TestFile_retriesShortWrites(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	f, err := open(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rotate.Wrap(&interrupted{f, root}, rotate.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	n, err := r.WriteString("123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("want 3 bytes, wrote %d bytes", n)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "123" {
+		t.Fatalf("want %q, got %q", "123", b)
+	}
+}
+
+var WriteBenchmarks = []struct {
+	Name   string
+	Config rotate.Config
+}{
+	{"passthrough", rotate.Config{}},
+	{"nolock", rotate.Config{NoLock: true, TotalBytes: rotate.GB}},
+	{"bytes", rotate.Config{Bytes: rotate.GB, Count: 2}},
+	{"interval", rotate.Config{Interval: 24 * time.Hour, Count: 2}},
+}
+
+func BenchmarkWrite(b *testing.B) {
+	msg := []byte("2018-10-01T15:04:05Z INFO message\n")
+	for _, bb := range WriteBenchmarks {
+		b.Run(bb.Name, func(b *testing.B) {
+			root, err := ioutil.TempDir("", "")
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			f, err := rotate.Open(filepath.Join(root, "a"), bb.Config)
+			if err != nil && err != rotate.ErrNotSupported {
+				b.Fatal(err)
+			}
+			defer f.Close()
+
+			b.SetBytes(int64(len(msg)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Write(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWrite_parallel(b *testing.B) {
+	msg := []byte("2018-10-01T15:04:05Z INFO message\n")
+	for _, bb := range WriteBenchmarks {
+		if bb.Config.NoLock {
+			continue
+		}
+		b.Run(bb.Name, func(b *testing.B) {
+			root, err := ioutil.TempDir("", "")
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			f, err := rotate.Open(filepath.Join(root, "a"), bb.Config)
+			if err != nil && err != rotate.ErrNotSupported {
+				b.Fatal(err)
+			}
+			defer f.Close()
+
+			b.SetBytes(int64(len(msg)))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := f.Write(msg); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestFile_allocs(t *testing.T) {
+	msg := []byte("2018-10-01T15:04:05Z INFO message\n")
+	for _, tt := range WriteBenchmarks {
+		t.Run(tt.Name, func(t *testing.T) {
+			root := touch(t)
+			defer os.RemoveAll(root)
+			f, err := rotate.Open(filepath.Join(root, "a"), tt.Config)
+			if err != nil && err != rotate.ErrNotSupported {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			write(t, f, "1") // a file is not empty
+
+			n := testing.AllocsPerRun(100, func() {
+				if _, err := f.Write(msg); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if n != 0 {
+				t.Errorf("want no allocations, got %v", n)
+			}
+		})
+	}
+}
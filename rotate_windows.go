@@ -0,0 +1,38 @@
+package rotate
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var getFinalPathNameByHandle = syscall.NewLazyDLL("kernel32.dll").NewProc("GetFinalPathNameByHandleW")
+
+// Dirname returns a directory containing fd.
+func Dirname(fd uintptr) (string, error) {
+	if err := getFinalPathNameByHandle.Find(); err != nil {
+		return "", ErrNotSupported
+	}
+	buf := make([]uint16, syscall.MAX_PATH)
+	for {
+		n, _, err := getFinalPathNameByHandle.Call(fd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0)
+		if n == 0 {
+			return "", err
+		}
+		if int(n) <= len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]uint16, n)
+	}
+	return filepath.Dir(trimPrefix(syscall.UTF16ToString(buf))), nil
+}
+
+// trimPrefix trims `\\?\` prefix returned by GetFinalPathNameByHandle.
+func trimPrefix(s string) string {
+	if strings.HasPrefix(s, `\\?\UNC\`) {
+		return `\\` + s[len(`\\?\UNC\`):]
+	}
+	return strings.TrimPrefix(s, `\\?\`)
+}
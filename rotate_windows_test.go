@@ -0,0 +1,32 @@
+package rotate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_longPath(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, strings.Repeat("d", 200), strings.Repeat("d", 200))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := rotate.Open(filepath.Join(dir, "a"), rotate.Config{Bytes: 1, Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// trigger rotation
+	write(t, r, "1")
+	write(t, r, "1")
+
+	exist(t, dir, "a.1")
+}
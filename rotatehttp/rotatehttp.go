@@ -0,0 +1,155 @@
+// Package rotatehttp serves rotation sets over HTTP.
+package rotatehttp
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+// Options configures Handler.
+type Options struct {
+	// Gzip compresses responses for clients accepting gzip encoding.
+	// Range requests are never compressed.
+	Gzip bool
+}
+
+// File describes a file of a rotation set.
+type File struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime"`
+	Compressed bool      `json:"compressed"`
+}
+
+// Handler returns http.Handler serving a rotation set of a file with name.
+//
+//     GET /        lists files as JSON array of File,
+//                  offset and limit query parameters paginate it
+//     GET /<file>  downloads a file, Range header is supported
+//
+// Mount it with http.StripPrefix to serve under a path.
+func Handler(name string, opt Options) http.Handler {
+	return &handler{
+		root: filepath.Dir(name),
+		base: filepath.Base(name),
+		opt:  opt,
+	}
+}
+
+type handler struct {
+	root string
+	base string
+	opt  Options
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		h.list(w, r)
+		return
+	}
+	names, err := rotate.List(h.root, h.base)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, s := range names {
+		if s == name {
+			h.serve(w, r, name)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	var opt rotate.ListOptions
+	for key, v := range map[string]*int{"offset": &opt.Offset, "limit": &opt.Limit} {
+		s := r.URL.Query().Get(key)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid "+key, http.StatusBadRequest)
+			return
+		}
+		*v = n
+	}
+	infos, err := rotate.ListInfo(h.root, h.base, opt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	files := make([]File, 0, len(infos))
+	for _, v := range infos {
+		files = append(files, File{Name: v.Name, Size: v.Size, ModTime: v.ModTime, Compressed: v.Compressed})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w = h.compress(w, r)
+	if c, ok := w.(*gzipWriter); ok {
+		defer c.Close()
+	}
+	_ = json.NewEncoder(w).Encode(files)
+}
+
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, name string) {
+	f, err := os.Open(filepath.Join(h.root, name))
+	if os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	v, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if r.Header.Get("Range") == "" {
+		w = h.compress(w, r)
+		if c, ok := w.(*gzipWriter); ok {
+			defer c.Close()
+			_, _ = io.Copy(c, f)
+			return
+		}
+	}
+	http.ServeContent(w, r, name, v.ModTime(), f)
+}
+
+// compress wraps w with gzip if enabled and accepted by a client.
+func (h *handler) compress(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if !h.opt.Gzip || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		return w
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	return &gzipWriter{w, gzip.NewWriter(w)}
+}
+
+type gzipWriter struct {
+	http.ResponseWriter
+	z *gzip.Writer
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) { return w.z.Write(b) }
+func (w *gzipWriter) Close() error                { return w.z.Close() }
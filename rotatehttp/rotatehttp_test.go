@@ -0,0 +1,127 @@
+package rotatehttp_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koorgoo/rotate/rotatehttp"
+)
+
+func setup(t *testing.T, opt rotatehttp.Options) (*httptest.Server, func()) {
+	root, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]string{"a": "current", "a.1": "rotated", "b": "other"} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := httptest.NewServer(rotatehttp.Handler(filepath.Join(root, "a"), opt))
+	return srv, func() {
+		srv.Close()
+		os.RemoveAll(root)
+	}
+}
+
+func get(t *testing.T, url string, header map[string]string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestHandler_list(t *testing.T) {
+	srv, teardown := setup(t, rotatehttp.Options{})
+	defer teardown()
+
+	resp := get(t, srv.URL+"/", nil)
+	defer resp.Body.Close()
+
+	var files []rotatehttp.File
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != "a" || files[1].Name != "a.1" {
+		t.Fatalf("want a and a.1, got %v", files)
+	}
+}
+
+func TestHandler_listPage(t *testing.T) {
+	srv, teardown := setup(t, rotatehttp.Options{})
+	defer teardown()
+
+	resp := get(t, srv.URL+"/?offset=1&limit=1", nil)
+	defer resp.Body.Close()
+
+	var files []rotatehttp.File
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name != "a.1" || files[0].Size != int64(len("rotated")) {
+		t.Fatalf("want a.1, got %v", files)
+	}
+
+	resp = get(t, srv.URL+"/?limit=x", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("want %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestHandler_range(t *testing.T) {
+	srv, teardown := setup(t, rotatehttp.Options{Gzip: true})
+	defer teardown()
+
+	resp := get(t, srv.URL+"/a.1", map[string]string{"Range": "bytes=0-2", "Accept-Encoding": "gzip"})
+	defer resp.Body.Close()
+
+	b, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || string(b) != "rot" {
+		t.Fatalf("want 206 %q, got %d %q", "rot", resp.StatusCode, b)
+	}
+}
+
+func TestHandler_gzip(t *testing.T) {
+	srv, teardown := setup(t, rotatehttp.Options{Gzip: true})
+	defer teardown()
+
+	resp := get(t, srv.URL+"/a.1", map[string]string{"Accept-Encoding": "gzip"})
+	defer resp.Body.Close()
+
+	z, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(z)
+	if string(b) != "rotated" {
+		t.Fatalf("want %q, got %q", "rotated", b)
+	}
+}
+
+func TestHandler_notFound(t *testing.T) {
+	srv, teardown := setup(t, rotatehttp.Options{})
+	defer teardown()
+
+	for _, s := range []string{"/b", "/a.2"} {
+		resp := get(t, srv.URL+s, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: want 404, got %d", s, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package rotatehttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+// WebhookOptions configures Webhook.
+type WebhookOptions struct {
+	// Client sends requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Retries is a number of retries of a failed request. Defaults to 3.
+	// If Retries < 0, a request is not retried.
+	Retries int
+	// Backoff is a delay before the first retry, doubled on each next one.
+	// Defaults to a second.
+	Backoff time.Duration
+	// Body sends a rotated file as a request body. Metadata is sent in
+	// X-Rotate-* headers then.
+	Body bool
+	// Location returns a location of a rotated file (e.g. a presigned URL)
+	// to include in metadata.
+	Location func(rotate.RotationInfo) (string, error)
+	// OnError is called when delivery fails after all retries.
+	OnError func(error)
+}
+
+// Payload is metadata sent by Webhook.
+type Payload struct {
+	Filename string    `json:"filename"`
+	Size     int64     `json:"size"`
+	First    time.Time `json:"first"`
+	Last     time.Time `json:"last"`
+	Location string    `json:"location,omitempty"`
+}
+
+// Webhook returns a hook for rotate.Config.OnRotate which POSTs metadata of
+// a rotated file to url. Delivery happens in background, so that writes are
+// not blocked.
+func Webhook(url string, opt WebhookOptions) func(rotate.RotationInfo) {
+	if opt.Client == nil {
+		opt.Client = http.DefaultClient
+	}
+	switch {
+	case opt.Retries == 0:
+		opt.Retries = 3
+	case opt.Retries < 0:
+		opt.Retries = 0
+	}
+	if opt.Backoff == 0 {
+		opt.Backoff = time.Second
+	}
+	return func(info rotate.RotationInfo) {
+		// A file is opened at once, as it is renamed on next rotation.
+		var f *os.File
+		if opt.Body && info.Filename != "" {
+			var err error
+			if f, err = os.Open(info.Filename); err != nil {
+				if opt.OnError != nil {
+					opt.OnError(err)
+				}
+				return
+			}
+		}
+		go func() {
+			if f != nil {
+				defer f.Close()
+			}
+			if err := deliver(url, opt, info, f); err != nil && opt.OnError != nil {
+				opt.OnError(err)
+			}
+		}()
+	}
+}
+
+func deliver(url string, opt WebhookOptions, info rotate.RotationInfo, f *os.File) (err error) {
+	p := Payload{
+		Filename: info.Filename,
+		Size:     info.Size,
+		First:    info.First,
+		Last:     info.Last,
+	}
+	if opt.Location != nil {
+		if p.Location, err = opt.Location(info); err != nil {
+			return err
+		}
+	}
+	d := opt.Backoff
+	for i := 0; ; i++ {
+		if err = post(url, opt, p, f); err == nil || i == opt.Retries {
+			return
+		}
+		time.Sleep(d)
+		d *= 2
+	}
+}
+
+func post(url string, opt WebhookOptions, p Payload, f *os.File) error {
+	var req *http.Request
+	var err error
+	if f != nil {
+		req, err = bodyRequest(url, p, f)
+	} else {
+		var b []byte
+		if b, err = json.Marshal(p); err == nil {
+			req, err = http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+			if err == nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	resp, err := opt.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("rotatehttp: %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+func bodyRequest(url string, p Payload, f *os.File) (*http.Request, error) {
+	v, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, ioutil.NopCloser(f))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = v.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Rotate-Filename", p.Filename)
+	req.Header.Set("X-Rotate-Size", strconv.FormatInt(p.Size, 10))
+	req.Header.Set("X-Rotate-First", p.First.Format(time.RFC3339Nano))
+	req.Header.Set("X-Rotate-Last", p.Last.Format(time.RFC3339Nano))
+	if p.Location != "" {
+		req.Header.Set("X-Rotate-Location", p.Location)
+	}
+	return req, nil
+}
@@ -0,0 +1,109 @@
+package rotatehttp_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+	"github.com/koorgoo/rotate/rotatehttp"
+)
+
+func TestWebhook(t *testing.T) {
+	payloads := make(chan rotatehttp.Payload, 1)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var p rotatehttp.Payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		payloads <- p
+	}))
+	defer srv.Close()
+
+	hook := rotatehttp.Webhook(srv.URL, rotatehttp.WebhookOptions{
+		Backoff: time.Millisecond,
+		OnError: func(err error) { t.Error(err) },
+	})
+	hook(rotate.RotationInfo{Filename: "a.1", Size: 1})
+
+	select {
+	case p := <-payloads:
+		if p.Filename != "a.1" || p.Size != 1 {
+			t.Fatalf("unexpected payload: %v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no request")
+	}
+}
+
+func TestWebhook_noRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+	hook := rotatehttp.Webhook(srv.URL, rotatehttp.WebhookOptions{
+		Retries: -1,
+		Backoff: time.Millisecond,
+		OnError: func(err error) { errs <- err },
+	})
+	hook(rotate.RotationInfo{Filename: "a.1", Size: 1})
+
+	select {
+	case <-errs:
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Fatalf("want 1 request, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error")
+	}
+}
+
+func TestWebhook_body(t *testing.T) {
+	root, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	name := filepath.Join(root, "a.1")
+	if err := ioutil.WriteFile(name, []byte("rotated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Rotate-Filename") != name {
+			t.Errorf("want %q, got %q", name, r.Header.Get("X-Rotate-Filename"))
+		}
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	hook := rotatehttp.Webhook(srv.URL, rotatehttp.WebhookOptions{Body: true})
+	hook(rotate.RotationInfo{Filename: name, Size: 7})
+
+	select {
+	case s := <-bodies:
+		if s != "rotated" {
+			t.Fatalf("want %q, got %q", "rotated", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no request")
+	}
+}
@@ -0,0 +1,116 @@
+package rotate
+
+import (
+	"os"
+	"time"
+)
+
+// Rotor is a file returned by Wrap and Open. It implements File.
+//
+// Features are added to Rotor as methods, so that File stays compatible
+// with *os.File. Package functions taking File accept Rotor as well.
+type Rotor struct {
+	f File // *file, *sharded or *handle
+}
+
+func (r *Rotor) Fd() uintptr                       { return r.f.Fd() }
+func (r *Rotor) Name() string                      { return r.f.Name() }
+func (r *Rotor) Stat() (os.FileInfo, error)        { return r.f.Stat() }
+func (r *Rotor) Sync() error                       { return r.f.Sync() }
+func (r *Rotor) Write(b []byte) (int, error)       { return r.f.Write(b) }
+func (r *Rotor) WriteString(s string) (int, error) { return r.f.WriteString(s) }
+func (r *Rotor) Close() error                      { return r.f.Close() }
+
+// Flush flushes buffered writes, see Config.Shards, and a record held by
+// Config.Multiline. It waits for compression of Config.Compress.
+func (r *Rotor) Flush() error {
+	if v, ok := r.f.(flusher); ok {
+		return v.Flush()
+	}
+	return nil
+}
+
+// Rotate rotates a file now. See ScheduleRotation.
+func (r *Rotor) Rotate() (RotationInfo, error) {
+	v := <-ScheduleRotation(r, now())
+	return v.Info, v.Err
+}
+
+// Detach stops rotation and returns a current file without closing it,
+// e.g. to pass its descriptor to a child process. Held and buffered writes
+// are flushed. r must not be used then.
+//
+// ErrShared is returned if a file is opened by Open more than once and
+// ErrNotSupported if a current file is not *os.File. r stays usable then.
+func (r *Rotor) Detach() (*os.File, error) {
+	d, ok := r.f.(detacher)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return d.detach()
+}
+
+// detacher is implemented by files which can be detached, see Detach.
+type detacher interface {
+	detach() (*os.File, error)
+}
+
+func (f *file) detach() (*os.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, os.ErrClosed
+	}
+	w, ok := f.w.(*os.File)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return w, f.stop()
+}
+
+func (s *sharded) detach() (*os.File, error) {
+	if _, ok := s.f.current().File.(*os.File); !ok {
+		return nil, ErrNotSupported
+	}
+	err := s.stop()
+	if err == os.ErrClosed {
+		return nil, err
+	}
+	w, derr := s.f.detach()
+	if err == nil {
+		err = derr
+	}
+	return w, err
+}
+
+func (h *handle) detach() (*os.File, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, os.ErrClosed
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	s := registry.files[h.key]
+	if s.refs > 1 {
+		return nil, ErrShared
+	}
+	d, ok := s.f.(detacher)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	w, err := d.detach()
+	if w != nil {
+		h.closed = true
+		delete(registry.files, h.key)
+	}
+	return w, err
+}
+
+// Rescan is like package function Rescan.
+func (r *Rotor) Rescan() error { return Rescan(r) }
+
+// NextRotation is like package function NextRotation.
+func (r *Rotor) NextRotation() (t time.Time, reason string, ok bool) {
+	return NextRotation(r)
+}
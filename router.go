@@ -0,0 +1,62 @@
+package rotate
+
+import "bytes"
+
+// Router dispatches lines to files of a pool named by a key function,
+// so that a single writer fans out into per-category files.
+//
+//     r := rotate.NewRouter(pool, rotate.PrefixKey(map[string]string{
+//         "[audit]": "audit.log",
+//     }, "app.log"))
+//     log.SetOutput(r)
+//
+// Each write must hold whole lines. A line with an empty key is dropped.
+type Router struct {
+	p   *Pool
+	key func(line []byte) string
+}
+
+// NewRouter returns Router writing a line to a file of p named by key.
+func NewRouter(p *Pool, key func(line []byte) string) *Router {
+	return &Router{p: p, key: key}
+}
+
+// Write writes lines of b. A trailing line without a newline is written
+// as is.
+func (r *Router) Write(b []byte) (n int, err error) {
+	for len(b) > 0 {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line = b[:i+1]
+		}
+		if name := r.key(line); name != "" {
+			var f File
+			if f, err = r.p.Get(name); err != nil {
+				return
+			}
+			if _, err = f.Write(line); err != nil {
+				if _, ok := err.(*Error); !ok {
+					return
+				}
+			}
+		}
+		n += len(line)
+		b = b[len(line):]
+	}
+	return n, nil
+}
+
+// PrefixKey returns a key function for Router which maps lines starting with
+// a key of names to its value and other lines to def. The longest matching
+// prefix wins.
+func PrefixKey(names map[string]string, def string) func(line []byte) string {
+	return func(line []byte) string {
+		key, match := def, ""
+		for prefix, name := range names {
+			if len(prefix) > len(match) && bytes.HasPrefix(line, []byte(prefix)) {
+				key, match = name, prefix
+			}
+		}
+		return key
+	}
+}
@@ -0,0 +1,52 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestRouter(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	p := rotate.NewPool(rotate.Config{})
+	r := rotate.NewRouter(p, rotate.PrefixKey(map[string]string{
+		"[audit]":      filepath.Join(root, "audit"),
+		"[audit:auth]": filepath.Join(root, "auth"),
+		"[debug]":      "",
+	}, filepath.Join(root, "app")))
+
+	s := "1\n[audit] 2\n[debug] 3\n[audit:auth] 4\n5"
+	n, err := r.Write([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(s) {
+		t.Fatalf("want %d bytes, wrote %d bytes", len(s), n)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"app":   "1\n5",
+		"audit": "[audit] 2\n",
+		"auth":  "[audit:auth] 4\n",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: want %q, got %q", name, want, b)
+		}
+	}
+
+	if _, err := p.Get(filepath.Join(root, "app")); err != os.ErrClosed {
+		t.Fatalf("want %v, got %v", os.ErrClosed, err)
+	}
+}
@@ -0,0 +1,56 @@
+package rotate
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// Sampler keeps 1 in N lines matching a predicate and all other lines,
+// e.g. to tame floods of debug lines. N can be changed at runtime without
+// reopening a file. It is safe for concurrent use. See Config.Sample.
+type Sampler struct {
+	match func(line []byte) bool
+	n     int64
+	seen  uint64
+}
+
+// NewSampler returns Sampler keeping 1 in n lines for which match returns
+// true. If n <= 1, all lines are kept.
+func NewSampler(match func(line []byte) bool, n int) *Sampler {
+	return &Sampler{match: match, n: int64(n)}
+}
+
+// SetN sets n of s.
+func (s *Sampler) SetN(n int) { atomic.StoreInt64(&s.n, int64(n)) }
+
+// N returns n of s.
+func (s *Sampler) N() int { return int(atomic.LoadInt64(&s.n)) }
+
+// Filter returns b without dropped lines. Each line of b, including
+// a trailing one without a newline, is sampled separately.
+func (s *Sampler) Filter(b []byte) []byte {
+	n := uint64(s.N())
+	if n <= 1 {
+		return b
+	}
+	var out []byte
+	for i := 0; i < len(b); {
+		j := len(b)
+		if k := bytes.IndexByte(b[i:], '\n'); k >= 0 {
+			j = i + k + 1
+		}
+		line := b[i:j]
+		keep := !s.match(line) || (atomic.AddUint64(&s.seen, 1)-1)%n == 0
+		switch {
+		case keep && out != nil:
+			out = append(out, line...)
+		case !keep && out == nil:
+			out = append(make([]byte, 0, len(b)), b[:i]...)
+		}
+		i = j
+	}
+	if out == nil {
+		return b
+	}
+	return out
+}
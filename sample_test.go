@@ -0,0 +1,41 @@
+package rotate_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_sample(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	s := rotate.NewSampler(func(line []byte) bool {
+		return bytes.HasPrefix(line, []byte("debug"))
+	}, 2)
+	r := ropen(t, root, "a", rotate.Config{Sample: s})
+
+	for _, v := range []string{"debug 1\ninfo 1\ndebug 2\n", "debug 3\n", "debug 4\n"} {
+		if n := write(t, r, v); n != len(v) {
+			t.Fatalf("want %d bytes, wrote %d bytes", len(v), n)
+		}
+	}
+	s.SetN(1)
+	write(t, r, "debug 5\ndebug 6\n")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "debug 1\ninfo 1\ndebug 3\ndebug 5\ndebug 6\n"
+	if string(b) != want {
+		t.Fatalf("want %q, got %q", want, b)
+	}
+}
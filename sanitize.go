@@ -0,0 +1,105 @@
+package rotate
+
+import "unicode/utf8"
+
+// Sanitize defines how writes are cleaned up before they hit a file, so
+// that files produced from arbitrary output stay parseable. Each write is
+// sanitized separately, so escape sequences and characters must not span
+// writes. The zero value leaves writes intact.
+type Sanitize struct {
+	// StripANSI removes ANSI escape sequences, e.g. terminal colors.
+	StripANSI bool
+	// ValidUTF8 replaces invalid UTF-8 bytes with U+FFFD.
+	ValidUTF8 bool
+	// MaxLine caps a length of a line in bytes without a newline.
+	// The rest of a line is replaced with Marker.
+	// If MaxLine == 0, lines are not capped.
+	MaxLine int
+	// Marker marks a capped line. Defaults to TruncationMarker.
+	Marker string
+}
+
+// TruncationMarker is a default Sanitize.Marker.
+const TruncationMarker = "..."
+
+func (s Sanitize) enabled() bool {
+	return s.StripANSI || s.ValidUTF8 || s.MaxLine > 0
+}
+
+func (s Sanitize) marker() string {
+	if s.Marker == "" {
+		return TruncationMarker
+	}
+	return s.Marker
+}
+
+// replacement is UTF-8 of U+FFFD.
+var replacement = []byte(string(utf8.RuneError))
+
+// sanitize returns b sanitized with Config.Sanitize. A length of a current
+// line is carried between writes.
+func (f *file) sanitize(b []byte) []byte {
+	s := f.c.Sanitize
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		if b[i] == 0x1b && s.StripANSI {
+			i = skipEscape(b, i)
+			continue
+		}
+		if b[i] == '\n' {
+			out = append(out, '\n')
+			f.lineLen, f.capped = 0, false
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		c := b[i : i+size]
+		if r == utf8.RuneError && size == 1 && s.ValidUTF8 {
+			c = replacement
+		}
+		i += size
+		if s.MaxLine > 0 {
+			if f.capped {
+				continue
+			}
+			if f.lineLen+len(c) > s.MaxLine {
+				out = append(out, s.marker()...)
+				f.capped = true
+				continue
+			}
+			f.lineLen += len(c)
+		}
+		out = append(out, c...)
+	}
+	return out
+}
+
+// skipEscape returns an index of b after an escape sequence at i.
+func skipEscape(b []byte, i int) int {
+	j := i + 1
+	if j == len(b) {
+		return j
+	}
+	switch b[j] {
+	case '[': // CSI: parameters, intermediates and a final byte
+		j++
+		for j < len(b) && b[j] >= 0x20 && b[j] < 0x40 {
+			j++
+		}
+		if j < len(b) && b[j] >= 0x40 && b[j] <= 0x7e {
+			j++
+		}
+		return j
+	case ']': // OSC: ends with BEL or ST
+		for j++; j < len(b); j++ {
+			if b[j] == 0x07 {
+				return j + 1
+			}
+			if b[j] == 0x1b && j+1 < len(b) && b[j+1] == '\\' {
+				return j + 2
+			}
+		}
+		return j
+	}
+	return j + 1
+}
@@ -0,0 +1,90 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+var SanitizeTests = []struct {
+	Sanitize rotate.Sanitize
+	Writes   []string
+	Want     string
+}{
+	{
+		rotate.Sanitize{StripANSI: true},
+		[]string{"\x1b[1;31merror\x1b[0m\n", "\x1b]0;title\x07ok\n"},
+		"error\nok\n",
+	},
+	{
+		rotate.Sanitize{ValidUTF8: true},
+		[]string{"a\xffb\n", "ü\n"},
+		"a�b\nü\n",
+	},
+	{
+		rotate.Sanitize{MaxLine: 3},
+		[]string{"12", "345\n", "1234\n"},
+		"123...\n123...\n",
+	},
+	{
+		rotate.Sanitize{MaxLine: 3, Marker: "~"},
+		[]string{"üü\n"},
+		"ü~\n",
+	},
+}
+
+func TestFile_sanitize(t *testing.T) {
+	for _, tt := range SanitizeTests {
+		root := touch(t, "a")
+		defer os.RemoveAll(root)
+
+		r := ropen(t, root, "a", rotate.Config{Sanitize: tt.Sanitize})
+		for _, s := range tt.Writes {
+			if n := write(t, r, s); n != len(s) {
+				t.Fatalf("want %d bytes, wrote %d bytes", len(s), n)
+			}
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.Want {
+			t.Errorf("%+v: want %q, got %q", tt.Sanitize, tt.Want, b)
+		}
+	}
+}
+
+func TestFile_redact(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	re := regexp.MustCompile(`password=\S+`)
+	r := ropen(t, root, "a", rotate.Config{
+		Redact: func(b []byte) []byte {
+			return re.ReplaceAll(b, []byte("password=***"))
+		},
+	})
+	s := "login password=secret ok\n"
+	if n := write(t, r, s); n != len(s) {
+		t.Fatalf("want %d bytes, wrote %d bytes", len(s), n)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "login password=*** ok\n"; string(b) != want {
+		t.Fatalf("want %q, got %q", want, b)
+	}
+}
@@ -0,0 +1,67 @@
+package rotate
+
+import (
+	"os"
+	"time"
+)
+
+// RotationResult is a result of a scheduled rotation.
+type RotationResult struct {
+	// Info describes a sealed file if Err is nil.
+	Info RotationInfo
+	Err  error
+}
+
+// ScheduleRotation rotates f at t regardless of Config policies, e.g. at
+// 23:59:59 for end-of-day processing, and sends a result to a returned
+// channel, which is closed then. If t has passed, f is rotated at once.
+// An empty file is rotated as well.
+//
+// f must be returned by Wrap or Open. ErrNotSupported is sent if f is not
+// rotated on a current system and os.ErrClosed if f is closed by t.
+func ScheduleRotation(f File, t time.Time) <-chan RotationResult {
+	ch := make(chan RotationResult, 1)
+	go func() {
+		defer close(ch)
+		ff, ok := unwrap(f)
+		if ok {
+			_, noop := ff.r.(*noop)
+			ok = !noop
+		}
+		if !ok {
+			ch <- RotationResult{Err: ErrNotSupported}
+			return
+		}
+		timer := time.NewTimer(t.Sub(now()))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ff.done:
+			ch <- RotationResult{Err: os.ErrClosed}
+			return
+		}
+		if v, ok := f.(flusher); ok {
+			_ = v.Flush() // buffered writes belong to a sealed file
+		}
+		ch <- ff.rotateAt()
+	}()
+	return ch
+}
+
+// rotateAt rotates a current file once it is not frozen, see
+// ScheduleRotation and WithFrozenFile.
+func (f *file) rotateAt() (v RotationResult) {
+	f.hold.Lock()
+	defer f.hold.Unlock()
+	f.mu.Lock()
+	if f.closed {
+		v.Err = os.ErrClosed
+	} else if v.Err = f.flushDedup(); v.Err == nil {
+		v.Info, v.Err = f.force(now())
+	}
+	infos := f.infos
+	f.infos = nil
+	f.mu.Unlock()
+	f.onRotate(infos)
+	return
+}
@@ -0,0 +1,222 @@
+package rotate
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// shardBytes is a size of a shard buffer which triggers a flush.
+	shardBytes = 64 * 1024
+	// shardFlush is an interval of background flushes of shards.
+	shardFlush = 100 * time.Millisecond
+)
+
+// sharded buffers writes in shards flushed to a file by a background
+// goroutine, so that concurrent writers do not contend for the lock of
+// a file. Shards are not per CPU.
+//
+// Every write gets a sequence number, which also picks its shard, from
+// a single atomic counter shared by all writers.
+// Messages of all shards are merged by sequence numbers on flush, so that
+// writes of a goroutine are never reordered.
+type sharded struct {
+	f       *file
+	shards  []shard
+	seq     uint64
+	mu      sync.RWMutex // held for reading by Write, see Close
+	closed  bool
+	kick    chan struct{}   // wakes loop once a shard is full
+	flushes chan chan error // requests of Flush
+	done    chan struct{}
+	wg      sync.WaitGroup
+	err     error // of the last flush by loop, see stop
+}
+
+// shard is a buffer of whole messages. ends are offsets of message ends
+// and seqs are their sequence numbers.
+type shard struct {
+	mu   sync.Mutex
+	buf  []byte
+	ends []int
+	seqs []uint64
+	// spare is a drained buffer owned by loop, see drain.
+	spare struct {
+		buf  []byte
+		ends []int
+		seqs []uint64
+	}
+}
+
+func newSharded(f *file, n int) *sharded {
+	s := &sharded{
+		f:       f,
+		shards:  make([]shard, n),
+		kick:    make(chan struct{}, 1),
+		flushes: make(chan chan error),
+		done:    make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.loop()
+	return s
+}
+
+// loop is the only writer of shards to a file.
+func (s *sharded) loop() {
+	defer s.wg.Done()
+	t := time.NewTicker(shardFlush)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := s.flush(); err != nil {
+				s.failed(err)
+			}
+		case <-s.kick:
+			if err := s.flush(); err != nil {
+				s.failed(err)
+			}
+		case ch := <-s.flushes:
+			ch <- s.flush()
+		case <-s.done:
+			s.err = s.flush()
+			return
+		}
+	}
+}
+
+func (s *sharded) Fd() uintptr                { return s.f.Fd() }
+func (s *sharded) Name() string               { return s.f.Name() }
+func (s *sharded) Stat() (os.FileInfo, error) { return s.f.Stat() }
+
+// Write buffers b in a shard.
+func (s *sharded) Write(b []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	seq := atomic.AddUint64(&s.seq, 1)
+	sh := &s.shards[seq%uint64(len(s.shards))]
+	sh.mu.Lock()
+	sh.buf = append(sh.buf, b...)
+	sh.ends = append(sh.ends, len(sh.buf))
+	sh.seqs = append(sh.seqs, seq)
+	full := len(sh.buf) >= shardBytes
+	sh.mu.Unlock()
+	if full {
+		select {
+		case s.kick <- struct{}{}:
+		default: // a flush is pending
+		}
+	}
+	return len(b), nil
+}
+
+func (s *sharded) WriteString(v string) (int, error) {
+	return s.Write([]byte(v))
+}
+
+// Flush makes loop write buffered messages to a file and waits for it.
+func (s *sharded) Flush() error {
+	ch := make(chan error, 1)
+	select {
+	case s.flushes <- ch:
+		return <-ch
+	case <-s.done:
+		return os.ErrClosed
+	}
+}
+
+// flush writes messages of all shards one by one in order of sequence
+// numbers, so that each is kept whole across rotations. Messages are
+// dropped on error. It is called by loop only.
+func (s *sharded) flush() (err error) {
+	type cursor struct {
+		sh    *shard
+		i     int
+		start int
+	}
+	var cs []cursor
+	for i := range s.shards {
+		if sh := &s.shards[i]; sh.drain() {
+			cs = append(cs, cursor{sh: sh})
+		}
+	}
+	for err == nil && len(cs) > 0 {
+		k := 0 // a cursor of the smallest sequence number
+		for j := range cs {
+			if cs[j].sh.spare.seqs[cs[j].i] < cs[k].sh.spare.seqs[cs[k].i] {
+				k = j
+			}
+		}
+		c := &cs[k]
+		end := c.sh.spare.ends[c.i]
+		if _, err = s.f.Write(c.sh.spare.buf[c.start:end]); err != nil {
+			if _, ok := err.(*Error); ok {
+				err = nil // rotation errors do not fail a write
+			}
+		}
+		c.i, c.start = c.i+1, end
+		if c.i == len(c.sh.spare.ends) {
+			cs = append(cs[:k], cs[k+1:]...)
+		}
+	}
+	return
+}
+
+// drain swaps buffered messages of sh with its spare buffer, so that
+// writers do not wait for a flush, and reports whether any are drained.
+func (sh *shard) drain() bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if len(sh.ends) == 0 {
+		return false
+	}
+	sp := &sh.spare
+	sh.buf, sp.buf = sp.buf[:0], sh.buf
+	sh.ends, sp.ends = sp.ends[:0], sh.ends
+	sh.seqs, sp.seqs = sp.seqs[:0], sh.seqs
+	return true
+}
+
+func (s *sharded) failed(err error) {
+	s.f.emit(Event{Type: FlushFailed, Filename: s.f.Name(), Err: err})
+}
+
+func (s *sharded) Sync() error {
+	err := s.Flush()
+	if serr := s.f.Sync(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// stop stops writes and loop, which flushes messages buffered by then,
+// and returns an error of the flush. It returns os.ErrClosed if s is
+// already stopped.
+func (s *sharded) stop() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return os.ErrClosed
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.done)
+	s.wg.Wait()
+	return s.err
+}
+
+func (s *sharded) Close() error {
+	err := s.stop()
+	if err == os.ErrClosed {
+		return err
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
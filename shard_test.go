@@ -0,0 +1,90 @@
+package rotate_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_shards(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Bytes: 1024, Count: 100, Shards: 4})
+
+	var want []string
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		for i := 0; i < 100; i++ {
+			want = append(want, fmt.Sprintf("%d:%d", g, i))
+		}
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				write(t, r, fmt.Sprintf("%d:%d\n", g, i))
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rd, err := rotate.NewReader(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+	b, err := ioutil.ReadAll(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Fields(string(b))
+	// Writes of a goroutine keep their order.
+	next := make(map[string]int)
+	for _, s := range got {
+		v := strings.SplitN(s, ":", 2)
+		if want := strconv.Itoa(next[v[0]]); v[1] != want {
+			t.Fatalf("goroutine %s: want %s, got %s", v[0], want, v[1])
+		}
+		next[v[0]]++
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("want %d lines, got %d lines", len(want), len(got))
+	}
+}
+
+func TestFile_shardsClose(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	r := ropen(t, root, "a", rotate.Config{Shards: 2})
+	write(t, r, "1")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != os.ErrClosed {
+		t.Fatalf("want %v, got %v", os.ErrClosed, err)
+	}
+	if _, err := r.Write([]byte("2")); err != os.ErrClosed {
+		t.Fatalf("want %v, got %v", os.ErrClosed, err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "1" {
+		t.Errorf("want %q, got %q", "1", b)
+	}
+}
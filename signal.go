@@ -0,0 +1,55 @@
+package rotate
+
+import (
+	"os"
+	"os/signal"
+)
+
+// HandleSignals flushes and syncs f when one of sig is received.
+// Then handling stops and the signal is raised again, so that its default
+// behaviour (usually exit) applies.
+//
+//     defer rotate.HandleSignals(f, os.Interrupt, syscall.SIGTERM)()
+//
+// If f has Flush method, it is called before Sync.
+// Call stop to stop handling without raising a signal.
+func HandleSignals(f File, sig ...os.Signal) (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sig...)
+
+	go func() {
+		select {
+		case s := <-c:
+			signal.Stop(c)
+			flushSync(f)
+			raise(s)
+		case <-done:
+			signal.Stop(c)
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+type flusher interface {
+	Flush() error
+}
+
+func flushSync(f File) {
+	if v, ok := f.(flusher); ok {
+		_ = v.Flush()
+	}
+	_ = f.Sync()
+}
+
+// raise sends s to the current process or exits if not possible.
+func raise(s os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(s)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
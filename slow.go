@@ -0,0 +1,19 @@
+package rotate
+
+// stager is implemented by rotators which report a stage of rotation in
+// progress, see Config.SlowRotation.
+type stager interface {
+	Stage() string
+}
+
+func (r *rotator) Stage() string {
+	s, _ := r.stage.Load().(string)
+	return s
+}
+
+// enter records stage s of rotation.
+func (r *rotator) enter(s string) {
+	if r.c.SlowRotation > 0 {
+		r.stage.Store(s)
+	}
+}
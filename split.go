@@ -0,0 +1,88 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// SplitMode defines how writes larger than Config.Bytes are split.
+type SplitMode int
+
+// Split modes.
+const (
+	// SplitNone writes a message to a single file regardless of its size.
+	SplitNone SplitMode = iota
+	// SplitBytes fills a current file up to Config.Bytes and continues
+	// a message in a next file.
+	SplitBytes
+	// SplitLines is like SplitBytes, but splits a message after a newline.
+	// A line longer than Config.Bytes is not split.
+	// With Config.JSONLines, SplitBytes works like SplitLines. With
+	// Config.Multiline, writes are split between records.
+	SplitLines
+)
+
+var splitModes = map[SplitMode]string{
+	SplitNone:  "none",
+	SplitBytes: "bytes",
+	SplitLines: "lines",
+}
+
+func (m SplitMode) String() string {
+	if s, ok := splitModes[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("SplitMode(%d)", int(m))
+}
+
+// split writes b in chunks fitting Config.Bytes, so that a current file is
+// rotated between them.
+func (f *file) split(b []byte) (n int, err error) {
+	if f.c.SplitWrites == SplitNone || f.c.Bytes <= 0 || f.c.SizeFunc != nil {
+		return f.put(b)
+	}
+	var rerr error
+	for len(b) > 0 {
+		room := f.c.Bytes - f.n
+		if room <= 0 {
+			room = f.c.Bytes // rotation is pending
+		}
+		chunk := b
+		if int64(len(b)) > room {
+			chunk = f.cut(b, int(room))
+		}
+		m, err := f.put(chunk)
+		n += m
+		if m < len(chunk) {
+			return n, err
+		}
+		if rerr == nil {
+			rerr = err // *Error with Config.RotationErrors
+		}
+		b = b[len(chunk):]
+	}
+	return n, rerr
+}
+
+// cut returns a head of b to write to a file with room bytes left.
+func (f *file) cut(b []byte, room int) []byte {
+	if f.c.Multiline && !f.c.JSONLines {
+		if p := lastGroup(b, room); p > 0 {
+			return b[:p]
+		}
+		if p := nextGroup(b, room); p > 0 {
+			return b[:p]
+		}
+		return b
+	}
+	if f.c.SplitWrites != SplitLines && !f.c.JSONLines {
+		return b[:room]
+	}
+	if i := bytes.LastIndexByte(b[:room], '\n'); i >= 0 {
+		return b[:i+1]
+	}
+	if i := bytes.IndexByte(b[room:], '\n'); i >= 0 {
+		return b[:room+i+1]
+	}
+	return b
+}
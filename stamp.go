@@ -0,0 +1,59 @@
+package rotate
+
+import (
+	"strconv"
+	"time"
+)
+
+// StampUnix is a Config.Stamp layout of Unix time in seconds.
+const StampUnix = "unix"
+
+// formatStamp formats t with Config.Stamp layout.
+func formatStamp(b []byte, layout string, t time.Time) []byte {
+	if layout == StampUnix {
+		return strconv.AppendInt(b, t.Unix(), 10)
+	}
+	return t.AppendFormat(b, layout)
+}
+
+// timestamp writes b prefixing each line with time of the write, see
+// Config.Stamp. A number of bytes of b written is returned.
+func (f *file) timestamp(b []byte) (int, error) {
+	prefix := append(formatStamp(nil, f.c.Stamp, f.c.now()), ' ')
+	var buf []byte
+	var at []int // offsets of prefixes in buf
+	for i := 0; i < len(b); {
+		if !f.midLine {
+			at = append(at, len(buf))
+			buf = append(buf, prefix...)
+		}
+		j := i
+		for j < len(b) && b[j] != '\n' {
+			j++
+		}
+		if j < len(b) {
+			j++ // a newline
+		}
+		buf = append(buf, b[i:j]...)
+		f.midLine = buf[len(buf)-1] != '\n'
+		i = j
+	}
+	m, err := f.split(buf)
+	if m == len(buf) {
+		return len(b), err
+	}
+	// A line of a partial write continues without a prefix.
+	f.midLine = m > 0 && buf[m-1] != '\n'
+	n := m
+	for _, pos := range at {
+		if pos >= m {
+			break
+		}
+		if end := pos + len(prefix); end <= m {
+			n -= len(prefix)
+		} else {
+			n -= m - pos
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,45 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+var StampTests = []struct {
+	Layout string
+	Want   string
+}{
+	{rotate.StampUnix, `^\d+ a\n\d+ bc\n\d+ d$`},
+	{time.RFC3339, `^\d{4}-\d\d-\d\dT\S+ a\n\S+ bc\n\S+ d$`},
+}
+
+func TestFile_stamp(t *testing.T) {
+	for _, tt := range StampTests {
+		root := touch(t, "a")
+		defer os.RemoveAll(root)
+
+		r := ropen(t, root, "a", rotate.Config{Stamp: tt.Layout})
+		for _, s := range []string{"a\nb", "c\n", "d"} {
+			if n := write(t, r, s); n != len(s) {
+				t.Fatalf("want %d bytes, wrote %d bytes", len(s), n)
+			}
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(root, "a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(tt.Want).Match(b) {
+			t.Errorf("%s: want %s, got %q", tt.Layout, tt.Want, b)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package rotate
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets are upper bounds of buckets of Histogram. They must not
+// be changed.
+var LatencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// Histogram counts durations by LatencyBuckets.
+type Histogram struct {
+	// Counts are numbers of durations in buckets: Counts[i] is a number of
+	// durations not exceeding LatencyBuckets[i] and greater than the
+	// previous bound. The last count is of longer durations.
+	Counts [len(LatencyBuckets) + 1]int64
+	// Sum is a sum of durations.
+	Sum time.Duration
+}
+
+// Stats are statistics of a file, see Rotor.Stats.
+type Stats struct {
+	// Rotations is a number of successful rotations.
+	Rotations int64
+	// Latency is a histogram of durations of rotations: renames of
+	// rotated files and reopening of a file. Compression and removals by
+	// retention policies are not included.
+	Latency Histogram
+}
+
+// latency records durations of rotations atomically, so that statistics
+// are read without waiting for a rotation.
+type latency struct {
+	counts [len(LatencyBuckets) + 1]int64
+	sum    int64
+}
+
+func (l *latency) add(d time.Duration) {
+	i := 0
+	for i < len(LatencyBuckets) && d > LatencyBuckets[i] {
+		i++
+	}
+	atomic.AddInt64(&l.counts[i], 1)
+	atomic.AddInt64(&l.sum, int64(d))
+}
+
+func (l *latency) stats() Stats {
+	var v Stats
+	for i := range l.counts {
+		v.Latency.Counts[i] = atomic.LoadInt64(&l.counts[i])
+		v.Rotations += v.Latency.Counts[i]
+	}
+	v.Latency.Sum = time.Duration(atomic.LoadInt64(&l.sum))
+	return v
+}
+
+// Stats returns statistics of r. It does not wait for a write or
+// rotation to complete. Zero Stats are returned if r is not rotated on
+// a current system.
+func (r *Rotor) Stats() Stats {
+	f, ok := unwrap(r)
+	if !ok {
+		return Stats{}
+	}
+	return f.latency.stats()
+}
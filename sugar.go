@@ -1,6 +1,14 @@
 package rotate
 
-import "os"
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
 
 // Common bytes.
 const (
@@ -10,11 +18,12 @@ const (
 	GB       = 1024 * MB
 )
 
-// OpenPerm is used in *Open shortcuts to create a file.
+// OpenPerm is used in *Open shortcuts to create a file unless Config.Perm
+// is set.
 const OpenPerm os.FileMode = 0644
 
 // MustWrap is like Wrap, but panics on error. ErrNotSupported is skipped.
-func MustWrap(f File, c Config) File {
+func MustWrap(f File, c Config) *Rotor {
 	r, err := Wrap(f, c)
 	if mustPanic(err) {
 		panic(err)
@@ -30,7 +39,7 @@ func mustPanic(err error) bool {
 }
 
 // MustOpen is like Open, but panics on error. ErrNotSupported is skipped.
-func MustOpen(name string, c Config) File {
+func MustOpen(name string, c Config) *Rotor {
 	f, err := Open(name, c)
 	if mustPanic(err) {
 		panic(err)
@@ -39,12 +48,36 @@ func MustOpen(name string, c Config) File {
 }
 
 // Open opens a file and wraps it.
-func Open(name string, c Config) (File, error) {
-	f, err := os.OpenFile(name, OpenFlag, OpenPerm)
+//
+// Symlinks in name are resolved, so that rotated files are placed next to
+// a real file. Files opened by a process for the same path share a single
+// rotator, so that they do not rotate the file independently. Such files
+// must be opened with the same Config, see ErrConfigMismatch. The file is
+// closed when all of them are closed.
+func Open(name string, c Config) (*Rotor, error) {
+	f, err := openShared(name, c, func(name string) (File, error) {
+		return openWrapped(name, c)
+	})
+	if f == nil {
+		return nil, err
+	}
+	return &Rotor{f}, err
+}
+
+func openWrapped(name string, c Config) (File, error) {
+	f, err := openFile(name, c.flag(), c.perm())
 	if err != nil {
 		return nil, err
 	}
-	r, err := Wrap(f, c)
+	gid, err := lookupGroup(c.Group)
+	if err == nil {
+		err = chgrp(f, gid)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r, err := wrap(f, c)
 	if err == ErrNotSupported {
 		return r, err
 	}
@@ -53,3 +86,91 @@ func Open(name string, c Config) (File, error) {
 	}
 	return r, err
 }
+
+// NewWriter is like Open, but returns only Write and Close of a file, so
+// that the file is not used directly behind a rotator's back.
+func NewWriter(name string, c Config) (io.WriteCloser, error) {
+	f, err := Open(name, c)
+	if f == nil {
+		return nil, err
+	}
+	return writer{f}, err
+}
+
+// writer hides methods of File except Write and Close.
+type writer struct{ f File }
+
+func (w writer) Write(b []byte) (int, error) { return w.f.Write(b) }
+
+func (w writer) Close() error { return w.f.Close() }
+
+// OpenContext is like Open, but returns ctx.Err() once ctx is done, e.g. if
+// a file is on an unresponsive network mount. A blocked open is not
+// interrupted: the file is closed in background once the open returns.
+func OpenContext(ctx context.Context, name string, c Config) (*Rotor, error) {
+	type result struct {
+		f   *Rotor
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		f, err := Open(name, c)
+		ch <- result{f, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.f, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-ch; r.f != nil {
+				_ = r.f.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// ParseBytes parses a human-readable size like "512", "10k", "10KB", "1.5GiB".
+// Units are powers of 1024 and case-insensitive.
+func ParseBytes(s string) (int64, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	unit := B
+	for _, u := range units {
+		for _, suffix := range u.suffixes {
+			if strings.HasSuffix(v, suffix) {
+				unit = u.bytes
+				v = strings.TrimSpace(strings.TrimSuffix(v, suffix))
+				goto PARSE
+			}
+		}
+	}
+PARSE:
+	f, err := strconv.ParseFloat(v, 64)
+	n := f * float64(unit)
+	if err != nil || f < 0 || math.IsNaN(n) || n >= math.MaxInt64 {
+		return 0, fmt.Errorf("rotate: invalid size %q", s)
+	}
+	return int64(n), nil
+}
+
+// FormatBytes formats n with the largest unit dividing it, e.g. "10MB".
+// The result is accepted by ParseBytes.
+func FormatBytes(n int64) string {
+	for _, u := range units {
+		if u.bytes > B && n != 0 && n%u.bytes == 0 {
+			return strconv.FormatInt(n/u.bytes, 10) + strings.ToUpper(u.suffixes[1])
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// units are ordered so that longer suffixes match first.
+var units = []struct {
+	bytes    int64
+	suffixes []string
+}{
+	{GB, []string{"gib", "gb", "g"}},
+	{MB, []string{"mib", "mb", "m"}},
+	{KB, []string{"kib", "kb", "k"}},
+	{B, []string{"b"}},
+}
@@ -0,0 +1,199 @@
+package rotate_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestNewWriter(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	w, err := rotate.NewWriter(filepath.Join(root, "a"), rotate.Config{Bytes: 1, Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.(rotate.File); ok {
+		t.Error("want a writer hiding File")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := w.Write([]byte("1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	exist(t, root, "a.1")
+}
+
+var ParseBytesTests = []struct {
+	S     string
+	Bytes int64
+	Err   bool
+}{
+	{"512", 512, false},
+	{"1b", 1, false},
+	{"10k", 10 * rotate.KB, false},
+	{"10KB", 10 * rotate.KB, false},
+	{"10 MiB", 10 * rotate.MB, false},
+	{"1.5g", 3 * rotate.GB / 2, false},
+	{"", 0, true},
+	{"-1", 0, true},
+	{"10x", 0, true},
+	{"inf", 0, true},
+	{"+Inf", 0, true},
+	{"nan", 0, true},
+	{"1e30gb", 0, true},
+}
+
+func TestParseBytes(t *testing.T) {
+	for _, tt := range ParseBytesTests {
+		n, err := rotate.ParseBytes(tt.S)
+		if (err != nil) != tt.Err {
+			t.Errorf("%q: unexpected error: %v", tt.S, err)
+		}
+		if n != tt.Bytes {
+			t.Errorf("%q: want %d, got %d", tt.S, tt.Bytes, n)
+		}
+	}
+}
+
+var FormatBytesTests = []struct {
+	Bytes int64
+	S     string
+}{
+	{0, "0"},
+	{512, "512"},
+	{10 * rotate.KB, "10KB"},
+	{1536, "1536"},
+	{100 * rotate.MB, "100MB"},
+	{2 * rotate.GB, "2GB"},
+}
+
+func TestFormatBytes(t *testing.T) {
+	for _, tt := range FormatBytesTests {
+		if s := rotate.FormatBytes(tt.Bytes); s != tt.S {
+			t.Errorf("%d: want %q, got %q", tt.Bytes, tt.S, s)
+		}
+	}
+}
+
+func TestOpenContext(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	f, err := rotate.OpenContext(context.Background(), filepath.Join(root, "a"), rotate.Config{})
+	if err != nil && err != rotate.ErrNotSupported {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	exist(t, root, "a")
+}
+
+func TestOpenContext_done(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A canceled open may still complete in background.
+	_, err := rotate.OpenContext(ctx, filepath.Join(root, "a"), rotate.Config{})
+	if err != nil && err != context.Canceled {
+		t.Fatalf("want %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestOpen_shared(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	name := filepath.Join(root, "a")
+	c := rotate.Config{Bytes: 1, Count: 3}
+	f1 := rotate.MustOpen(name, c)
+	f2 := rotate.MustOpen(filepath.Join(root, ".", "a"), c)
+
+	write(t, f1, "1")
+	write(t, f2, "2") // rotation
+	write(t, f1, "3") // rotation
+
+	exist(t, root, "a.2")
+	notExist(t, root, "a.3")
+
+	if _, err := rotate.Open(name, rotate.Config{Bytes: 2, Count: 3}); err != rotate.ErrConfigMismatch {
+		t.Fatalf("want %v, got %v", rotate.ErrConfigMismatch, err)
+	}
+
+	if err := f1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f1.Write([]byte("x")); err != os.ErrClosed {
+		t.Fatalf("want %v, got %v", os.ErrClosed, err)
+	}
+	write(t, f2, "4") // still open
+	if err := f2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f2.Close(); err != os.ErrClosed {
+		t.Fatalf("want %v, got %v", os.ErrClosed, err)
+	}
+}
+
+func TestOpen_symlink(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	if err := os.Mkdir(filepath.Join(root, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "data", "app.log.real"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "data"), filepath.Join(root, "log")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink("app.log.real", filepath.Join(root, "data", "app.log")); err != nil {
+		t.Skip(err)
+	}
+
+	f := rotate.MustOpen(filepath.Join(root, "log", "app.log"), rotate.Config{Bytes: 1, Count: 2})
+	defer f.Close()
+
+	write(t, f, "1")
+	write(t, f, "2") // rotation
+
+	exist(t, filepath.Join(root, "data"), "app.log.real.1")
+	exist(t, filepath.Join(root, "data"), "app.log.real")
+}
+
+func TestOpen_flagAndPerm(t *testing.T) {
+	root := touch(t)
+	defer os.RemoveAll(root)
+
+	f, err := rotate.Open(filepath.Join(root, "a"), rotate.Config{Perm: 0600})
+	if err != nil && err != rotate.ErrNotSupported {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	v, err := stat(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && v.Mode().Perm() != 0600 {
+		t.Fatalf("want %s, got %s", os.FileMode(0600), v.Mode().Perm())
+	}
+
+	// a file opened without O_CREATE must exist
+	_, err = rotate.Open(filepath.Join(root, "b"), rotate.Config{Flag: os.O_WRONLY})
+	if !os.IsNotExist(err) {
+		t.Fatalf("want not exist error, got %v", err)
+	}
+}
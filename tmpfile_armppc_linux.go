@@ -0,0 +1,8 @@
+// +build linux
+// +build arm arm64 ppc64 ppc64le
+
+package rotate
+
+// oTmpfile is O_TMPFILE: __O_TMPFILE | O_DIRECTORY, which is 040000 on
+// these architectures.
+const oTmpfile = 0x404000
@@ -0,0 +1,7 @@
+// +build linux
+// +build !arm,!arm64,!ppc64,!ppc64le
+
+package rotate
+
+// oTmpfile is O_TMPFILE: __O_TMPFILE | O_DIRECTORY.
+const oTmpfile = 0x410000
@@ -0,0 +1,147 @@
+package rotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ChecksumExt is an extension of a checksum sidecar of a rotated file in
+// sha256sum format, e.g. a.1.gz.sha256. See Verify.
+const ChecksumExt = ".sha256"
+
+// Checks of Verify.
+const (
+	CheckChain       = "chain"
+	CheckChecksum    = "checksum"
+	CheckManifest    = "manifest"
+	CheckCompression = "compression"
+)
+
+// Problem is a problem of a rotation set found by Verify.
+type Problem struct {
+	File  string `json:"file"`
+	Check string `json:"check"`
+	Error string `json:"error"`
+}
+
+// Report is a result of Verify.
+type Report struct {
+	// Files is a number of verified rotated files.
+	Files    int       `json:"files"`
+	Problems []Problem `json:"problems"`
+}
+
+// OK reports whether no problems are found.
+func (r Report) OK() bool { return len(r.Problems) == 0 }
+
+// Verify checks rotated files of base in root for periodic integrity jobs:
+// counters of rotated files have no gaps or duplicates, checksum sidecars
+// (see ChecksumExt) match files, a manifest (see Config.Manifest) is
+// readable and its generation is not less than a number of rotated files,
+// and compressed files are decompressed without errors. Entries of
+// a manifest are not checked against files.
+//
+// Problems are listed in a report. An error is returned only if files
+// cannot be listed.
+func Verify(root, base string) (Report, error) {
+	base = filepath.Base(base)
+	names, err := List(root, base)
+	if err != nil {
+		return Report{}, err
+	}
+	var rep Report
+	add := func(name, check string, err error) {
+		rep.Problems = append(rep.Problems, Problem{File: name, Check: check, Error: err.Error()})
+	}
+
+	counters := make(map[int64]string)
+	var max int64
+	for _, s := range names {
+		if s == base {
+			continue
+		}
+		rep.Files++
+		_, n, t, _ := parse(s)
+		if t.IsZero() {
+			if v, ok := counters[n]; ok {
+				add(s, CheckChain, fmt.Errorf("duplicate of %s", v))
+			}
+			counters[n] = s
+			if n > max {
+				max = n
+			}
+		}
+		name := filepath.Join(root, s)
+		if err := verifyChecksum(name); err != nil {
+			add(s, CheckChecksum, err)
+		}
+		if strings.HasSuffix(s, gzipExt) {
+			if err := verifyGzip(name); err != nil {
+				add(s, CheckCompression, err)
+			}
+		}
+	}
+	for n := int64(1); n < max; n++ {
+		if _, ok := counters[n]; !ok {
+			add(Numeric.format(base, n, time.Time{}, ""), CheckChain, os.ErrNotExist)
+		}
+	}
+
+	mname := manifestName(base)
+	if _, err := os.Stat(filepath.Join(root, mname)); err == nil {
+		m, err := loadManifest(filepath.Join(root, mname))
+		switch {
+		case err != nil:
+			add(mname, CheckManifest, err)
+		case m.Generation < int64(rep.Files):
+			add(mname, CheckManifest, fmt.Errorf("generation %d is less than %d rotated files", m.Generation, rep.Files))
+		}
+	}
+	return rep, nil
+}
+
+// verifyChecksum checks a file at name against its sidecar if it exists.
+func verifyChecksum(name string) error {
+	b, err := ioutil.ReadFile(name + ChecksumExt)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fields := bytes.Fields(b)
+	if len(fields) == 0 {
+		return fmt.Errorf("empty %s", ChecksumExt)
+	}
+	v, err := sum(name)
+	if err != nil {
+		return err
+	}
+	if s := hex.EncodeToString(v); s != strings.ToLower(string(fields[0])) {
+		return fmt.Errorf("sha256 %s does not match %s", s, fields[0])
+	}
+	return nil
+}
+
+// verifyGzip reads a gzip file at name to the end.
+func verifyGzip(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	z, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(ioutil.Discard, z)
+	return err
+}
@@ -0,0 +1,91 @@
+package rotate_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestVerify(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+	for name, s := range map[string]string{
+		"a.1":         "1",
+		"a.1.sha256":  "6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b  a.1\n",
+		"a.2.gz":      "not gzip",
+		"a.4":         "4",
+		"a.4.sha256":  "6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b  a.4\n",
+		".a.manifest": `{"generation":1}`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rep, err := rotate.Verify(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Files != 3 {
+		t.Errorf("want 3 files, got %d", rep.Files)
+	}
+	var got [][2]string
+	for _, p := range rep.Problems {
+		got = append(got, [2]string{p.File, p.Check})
+	}
+	want := [][2]string{
+		{"a.2.gz", rotate.CheckCompression},
+		{"a.4", rotate.CheckChecksum},
+		{"a.3", rotate.CheckChain},
+		{".a.manifest", rotate.CheckManifest},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	root := touch(t, "a", "a.1", "a.3")
+	defer os.RemoveAll(root)
+	var gz bytes.Buffer
+	z := gzip.NewWriter(&gz)
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]string{
+		"a.5.gz":      gz.String(),
+		"a.6.gz":      "not gzip",
+		".a.manifest": `{"generation":1}`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps, err := rotate.Repair(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rotate.Step{
+		{Op: rotate.OpRename, Name: "a.6.gz", To: filepath.Join(rotate.QuarantineDir, "a.6.gz")},
+		{Op: rotate.OpRename, Name: "a.3", To: "a.2"},
+		{Op: rotate.OpRename, Name: "a.5.gz", To: "a.3.gz"},
+		{Op: rotate.OpCreate, Name: ".a.manifest"},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Fatalf("want %v, got %v", want, steps)
+	}
+	rep, err := rotate.Verify(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() {
+		t.Errorf("want no problems, got %+v", rep.Problems)
+	}
+}
@@ -0,0 +1,82 @@
+package rotate
+
+import (
+	"os"
+	"time"
+)
+
+// reopener is implemented by rotators able to reopen a current file.
+type reopener interface {
+	Reopen() (File, error)
+}
+
+// watch checks a file every d until the file is closed.
+func (f *file) watch(d time.Duration) {
+	t := time.NewTicker(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			f.mu.Lock()
+			if !f.closed {
+				f.check()
+			}
+			f.mu.Unlock()
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// check re-syncs f with a file on disk, which might have been removed,
+// renamed or truncated by external tools.
+func (f *file) check() {
+	name := f.w.Name()
+	cur, err := f.w.Stat()
+	if err != nil {
+		f.emit(Event{Type: WatchFailed, Filename: name, Err: err})
+		return
+	}
+	v, err := os.Stat(name)
+	if err != nil && !os.IsNotExist(err) {
+		f.emit(Event{Type: WatchFailed, Filename: name, Err: err})
+		return
+	}
+	if err != nil || !os.SameFile(cur, v) {
+		f.reopen()
+		return
+	}
+	f.truncated(v.Size())
+}
+
+// truncated re-syncs the size counter if a file has shrunk to size, e.g. by
+// logrotate copytruncate or an operator.
+func (f *file) truncated(size int64) bool {
+	if size >= f.n {
+		return false
+	}
+	f.resize(size)
+	f.emit(Event{Type: FileTruncated, Filename: f.w.Name(), N: size})
+	return true
+}
+
+func (f *file) reopen() {
+	r, ok := f.r.(reopener)
+	if !ok {
+		return
+	}
+	w, err := r.Reopen()
+	if err == nil {
+		var v os.FileInfo
+		v, err = w.Stat()
+		if err == nil {
+			f.resize(v.Size())
+		}
+	}
+	f.setCurrent(w)
+	if err != nil {
+		f.emit(Event{Type: WatchFailed, Filename: w.Name(), Err: err})
+		return
+	}
+	f.emit(Event{Type: FileReopened, Filename: w.Name()})
+}
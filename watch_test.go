@@ -0,0 +1,62 @@
+// +build linux
+
+package rotate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+func TestFile_watchReopensRemovedFile(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	events := make(chan rotate.Event, 1)
+	r := ropen(t, root, "a", rotate.Config{
+		Watch:   time.Millisecond,
+		OnEvent: func(e rotate.Event) { events <- e },
+	})
+	defer r.Close()
+
+	remove(t, root, "a")
+
+	select {
+	case e := <-events:
+		if e.Type != rotate.FileReopened {
+			t.Fatalf("want FileReopened, got %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	exist(t, root, "a")
+}
+
+func TestFile_watchDetectsTruncation(t *testing.T) {
+	root := touch(t, "a")
+	defer os.RemoveAll(root)
+
+	events := make(chan rotate.Event, 1)
+	r := ropen(t, root, "a", rotate.Config{
+		Watch:   time.Millisecond,
+		OnEvent: func(e rotate.Event) { events <- e },
+	})
+	defer r.Close()
+
+	write(t, r, "123")
+	if err := os.Truncate(filepath.Join(root, "a"), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-events:
+		if e.Type != rotate.FileTruncated || e.N != 1 {
+			t.Fatalf("want FileTruncated to 1 byte, got %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
@@ -0,0 +1,82 @@
+package rotate
+
+import "time"
+
+// Window is a daily time range set by offsets from local midnight, or UTC
+// midnight with Config.UseUTC.
+// If From > To, the window wraps around midnight.
+//
+//     Window{From: 9 * time.Hour, To: 10 * time.Hour}  // 09:00-10:00
+//     Window{From: 23 * time.Hour, To: 1 * time.Hour}  // 23:00-01:00
+//
+type Window struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// Contains reports whether t is within w. From is inclusive, To is exclusive.
+func (w Window) Contains(t time.Time) bool {
+	d := sinceMidnight(t)
+	if w.From <= w.To {
+		return w.From <= d && d < w.To
+	}
+	return w.From <= d || d < w.To
+}
+
+func inWindows(windows []Window, t time.Time) bool {
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// afterWindows returns the first moment at or after t outside of windows.
+func afterWindows(windows []Window, t time.Time) time.Time {
+	// Each step moves t to the end of a window, so overlapping windows
+	// take at most len(windows) steps.
+	for i := 0; i <= len(windows); i++ {
+		moved := false
+		for _, w := range windows {
+			if w.Contains(t) {
+				t = w.end(t)
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return t
+}
+
+// end returns the end of w containing t.
+func (w Window) end(t time.Time) time.Time {
+	y, m, d := t.Date()
+	end := time.Date(y, m, d, 0, 0, 0, 0, t.Location()).Add(w.To)
+	if end.Before(t) {
+		end = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location()).Add(w.To)
+	}
+	return end
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	y, m, d := t.Date()
+	return t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+}
+
+// nextBoundary returns the first boundary of interval d after t.
+// Boundaries are aligned to local midnight of t. Intervals shorter than
+// a day restart at each midnight.
+func nextBoundary(t time.Time, d time.Duration) time.Time {
+	y, m, day := t.Date()
+	midnight := time.Date(y, m, day, 0, 0, 0, 0, t.Location())
+	next := midnight.Add((sinceMidnight(t)/d + 1) * d)
+	if d < 24*time.Hour {
+		if tomorrow := time.Date(y, m, day+1, 0, 0, 0, 0, t.Location()); next.After(tomorrow) {
+			return tomorrow
+		}
+	}
+	return next
+}
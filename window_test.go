@@ -0,0 +1,76 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koorgoo/rotate"
+)
+
+var WindowTests = []struct {
+	Window   rotate.Window
+	Hour     int
+	Contains bool
+}{
+	{rotate.Window{From: 9 * time.Hour, To: 10 * time.Hour}, 8, false},
+	{rotate.Window{From: 9 * time.Hour, To: 10 * time.Hour}, 9, true},
+	{rotate.Window{From: 9 * time.Hour, To: 10 * time.Hour}, 10, false},
+	{rotate.Window{From: 23 * time.Hour, To: 1 * time.Hour}, 23, true},
+	{rotate.Window{From: 23 * time.Hour, To: 1 * time.Hour}, 0, true},
+	{rotate.Window{From: 23 * time.Hour, To: 1 * time.Hour}, 1, false},
+	{rotate.Window{From: 23 * time.Hour, To: 1 * time.Hour}, 12, false},
+}
+
+func TestWindow_Contains(t *testing.T) {
+	for _, tt := range WindowTests {
+		at := time.Date(2018, 10, 1, tt.Hour, 0, 0, 0, time.Local)
+		if v := tt.Window.Contains(at); v != tt.Contains {
+			t.Errorf("%v at %d:00: want %v, got %v", tt.Window, tt.Hour, tt.Contains, v)
+		}
+	}
+}
+
+var BoundaryTests = []struct {
+	Time     string
+	Interval time.Duration
+	Next     string
+}{
+	{"2018-10-01T10:30:00Z", 24 * time.Hour, "2018-10-02T00:00:00Z"},
+	{"2018-10-01T10:30:00Z", 6 * time.Hour, "2018-10-01T12:00:00Z"},
+	{"2018-10-01T12:00:00Z", 6 * time.Hour, "2018-10-01T18:00:00Z"},
+	{"2018-10-01T10:30:00Z", 7 * time.Hour, "2018-10-01T14:00:00Z"},
+	{"2018-10-01T22:00:00Z", 5 * time.Hour, "2018-10-02T00:00:00Z"}, // restarts at midnight
+	{"2018-10-01T10:30:00Z", 48 * time.Hour, "2018-10-03T00:00:00Z"},
+}
+
+func TestNextRotation_boundary(t *testing.T) {
+	for _, tt := range BoundaryTests {
+		root := touch(t)
+		defer os.RemoveAll(root)
+
+		name := filepath.Join(root, "a")
+		if err := ioutil.WriteFile(name, []byte("1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime, _ := time.Parse(time.RFC3339, tt.Time)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := rotate.Open(name, rotate.Config{Interval: tt.Interval, UseUTC: true})
+		if err == rotate.ErrNotSupported {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, _, _ := r.NextRotation()
+		r.Close()
+		if want, _ := time.Parse(time.RFC3339, tt.Next); !next.Equal(want) {
+			t.Errorf("%s every %s: want %s, got %s", tt.Time, tt.Interval, want, next)
+		}
+	}
+}